@@ -15,7 +15,11 @@ pub enum Algorithm {
         alias = "AES128GCM",
         alias = "AES_128_GCM",
         alias = "aes-128-gcm",
-        alias = "aes_128_gcm"
+        alias = "aes_128_gcm",
+        alias = "AESGCM128",
+        alias = "aesgcm128",
+        alias = "AES-GCM-128",
+        alias = "aes-gcm-128"
     )]
     #[strum(serialize = "AES-128-GCM")]
     Aes_128_Gcm,
@@ -25,7 +29,11 @@ pub enum Algorithm {
         alias = "AES256GCM",
         alias = "AES_256_GCM",
         alias = "aes-256-gcm",
-        alias = "aes_256_gcm"
+        alias = "aes_256_gcm",
+        alias = "AESGCM256",
+        alias = "aesgcm256",
+        alias = "AES-GCM-256",
+        alias = "aes-gcm-256"
     )]
     #[strum(serialize = "AES-256-GCM")]
     Aes_256_Gcm,
@@ -96,7 +104,13 @@ pub enum Algorithm {
         alias = "SHA2-256",
         alias = "sha2-256",
         alias = "Sha256",
-        alias = "Sha2_256"
+        alias = "Sha2_256",
+        alias = "HMAC-SHA256",
+        alias = "hmac-sha256",
+        alias = "HMAC-SHA-256",
+        alias = "hmac-sha-256",
+        alias = "HMAC_SHA256",
+        alias = "hmac_sha256"
     )]
     #[strum(serialize = "SHA-256")]
     Sha2_256,
@@ -113,7 +127,13 @@ pub enum Algorithm {
         alias = "SHA2-384",
         alias = "sha2-384",
         alias = "Sha384",
-        alias = "Sha2_384"
+        alias = "Sha2_384",
+        alias = "HMAC-SHA384",
+        alias = "hmac-sha384",
+        alias = "HMAC-SHA-384",
+        alias = "hmac-sha-384",
+        alias = "HMAC_SHA384",
+        alias = "hmac_sha384"
     )]
     #[strum(serialize = "SHA-384")]
     Sha2_384,
@@ -130,10 +150,31 @@ pub enum Algorithm {
         alias = "SHA2-512",
         alias = "sha2-512",
         alias = "Sha512",
-        alias = "Sha2_512"
+        alias = "Sha2_512",
+        alias = "HMAC-SHA512",
+        alias = "hmac-sha512",
+        alias = "HMAC-SHA-512",
+        alias = "hmac-sha-512",
+        alias = "HMAC_SHA512",
+        alias = "hmac_sha512"
     )]
     #[strum(serialize = "SHA-512")]
     Sha2_512,
+    /// MAC - HMAC Sha512/224
+    #[clap(
+        alias = "sha512_224",
+        alias = "sha-512-224",
+        alias = "SHA512_224",
+        alias = "SHA-512-224",
+        alias = "SHA2_512_224",
+        alias = "sha2_512_224",
+        alias = "SHA2-512-224",
+        alias = "sha2-512-224",
+        alias = "Sha512_224",
+        alias = "Sha2_512_224"
+    )]
+    #[strum(serialize = "SHA-512-224")]
+    Sha2_512_224,
     /// MAC - HMAC Sha3-256
     #[clap(
         alias = "sha3_256",
@@ -254,6 +295,22 @@ pub enum Algorithm {
     Ed25519,
 }
 impl Algorithm {
+    /// Parses `value` as the name or an alias of an algorithm, ignoring case,
+    /// dashes and underscores.
+    pub fn parse(value: &str) -> Result<Self, String> {
+        let normalized = normalize(value);
+        Self::value_variants()
+            .iter()
+            .find(|algorithm| {
+                algorithm.to_possible_value().map_or(false, |pv| {
+                    pv.get_name_and_aliases()
+                        .any(|name| normalize(name) == normalized)
+                })
+            })
+            .cloned()
+            .ok_or_else(|| format!("unknown algorithm: {value}"))
+    }
+
     pub fn kind(&self) -> Kind {
         match self {
             Algorithm::Aes_128_Gcm
@@ -267,6 +324,7 @@ impl Algorithm {
             | Algorithm::Sha2_256
             | Algorithm::Sha2_384
             | Algorithm::Sha2_512
+            | Algorithm::Sha2_512_224
             | Algorithm::Sha3_256
             | Algorithm::Sha3_224
             | Algorithm::Sha3_384
@@ -323,6 +381,7 @@ impl TryFrom<Algorithm> for navajo::mac::Algorithm {
             Algorithm::Sha2_256 => Ok(navajo::mac::Algorithm::Sha256),
             Algorithm::Sha2_384 => Ok(navajo::mac::Algorithm::Sha384),
             Algorithm::Sha2_512 => Ok(navajo::mac::Algorithm::Sha512),
+            Algorithm::Sha2_512_224 => Ok(navajo::mac::Algorithm::Sha512_224),
             Algorithm::Sha3_256 => Ok(navajo::mac::Algorithm::Sha3_256),
             Algorithm::Sha3_224 => Ok(navajo::mac::Algorithm::Sha3_224),
             Algorithm::Sha3_384 => Ok(navajo::mac::Algorithm::Sha3_384),
@@ -334,3 +393,61 @@ impl TryFrom<Algorithm> for navajo::mac::Algorithm {
         }
     }
 }
+
+fn normalize(value: &str) -> String {
+    value
+        .chars()
+        .filter(|c| *c != '-' && *c != '_')
+        .map(|c| c.to_ascii_lowercase())
+        .collect()
+}
+
+#[cfg(test)]
+mod tests {
+    use super::*;
+
+    #[test]
+    fn test_parse_aliases() {
+        for (value, expected) in [
+            ("SHA2-256", Algorithm::Sha2_256),
+            ("SHA-256", Algorithm::Sha2_256),
+            ("Sha-256", Algorithm::Sha2_256),
+            ("sha256", Algorithm::Sha2_256),
+            ("Hmac-Sha256", Algorithm::Sha2_256),
+            ("hmac_sha_512", Algorithm::Sha2_512),
+            ("sha2-512-224", Algorithm::Sha2_512_224),
+            ("Sha3_384", Algorithm::Sha3_384),
+            ("AES-256-GCM", Algorithm::Aes_256_Gcm),
+            ("AESGCM256", Algorithm::Aes_256_Gcm),
+            ("aesgcm256", Algorithm::Aes_256_Gcm),
+            ("Aes_128_Gcm", Algorithm::Aes_128_Gcm),
+            ("XChaCha20-Poly1305", Algorithm::Xchacha20Poly1305),
+            ("chacha20poly1305", Algorithm::Chacha20Poly1305),
+            ("CMAC-AES-192", Algorithm::Aes_192),
+            ("aes-siv", Algorithm::AesSiv),
+            ("Blake3", Algorithm::Blake3),
+            ("es256", Algorithm::Es256),
+        ] {
+            assert_eq!(Algorithm::parse(value), Ok(expected), "{value}");
+        }
+    }
+
+    #[test]
+    fn test_parse_agrees_with_from_str() {
+        for value in ["SHA-256", "AES-256-GCM", "aes_128", "ED25519"] {
+            assert_eq!(
+                Algorithm::parse(value).ok(),
+                Algorithm::from_str(value, true).ok(),
+                "{value}"
+            );
+        }
+    }
+
+    #[test]
+    fn test_parse_unknown() {
+        for value in ["", "SHA-1", "AES-512-GCM", "hmac", "sha256gcm"] {
+            assert!(Algorithm::parse(value).is_err(), "{value}");
+            assert!(Algorithm::from_str(value, true).is_err(), "{value}");
+        }
+    }
+}
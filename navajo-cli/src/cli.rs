@@ -1,4 +1,8 @@
-use std::path::PathBuf;
+use std::{
+    path::{Path, PathBuf},
+    pin::Pin,
+    task::{ready, Context, Poll},
+};
 
 use crate::{algorithm::Algorithm, envelope::Envelope, secret_store::SecretStore};
 use clap::{Parser, Subcommand};
@@ -6,7 +10,7 @@ use navajo::{
     primitive::{Kind, Primitive},
     Aead, Daead, Mac, Signer,
 };
-use tokio::io::{AsyncRead, AsyncWrite};
+use tokio::io::{AsyncRead, AsyncWrite, ReadBuf};
 use url::Url;
 
 #[derive(Debug, Parser)]
@@ -86,6 +90,7 @@ impl Command {
 #[derive(Debug, Parser)]
 pub struct New {
     /// Specifies the algorithm to use for the first key in the keyring.
+    #[arg(value_parser = Algorithm::parse)]
     pub algorithm: Algorithm,
     #[command(flatten)]
     pub metadata: Metadata,
@@ -171,6 +176,7 @@ pub struct AddKey {
     ///     
     /// Errors if the algorithm is not of the same primitive (AEAD, DAEAD,
     /// Signature, MAC) as the keyring.
+    #[arg(value_parser = Algorithm::parse)]
     pub algorithm: Algorithm,
     /// Metadata in the form of JSON to associate with the key, if any.
     ///
@@ -343,22 +349,41 @@ pub struct IoArgs {
     /// The output file to write the keyring to.
     ///
     /// If not specified, stdout is used
+    ///
+    /// On unix, the file is created with, or restricted to, 0600 permissions.
     pub output: Option<PathBuf>,
+
+    #[arg(long = "force", short = 'f')]
+    /// Write to the output file even if it is currently world-accessible, in
+    /// which case its permissions are first restricted to 0600.
+    pub force: bool,
+
+    #[arg(value_name = "BYTES", long = "max-input", default_value_t = DEFAULT_MAX_INPUT)]
+    /// The maximum number of bytes to read from the input before failing.
+    pub max_input: u64,
 }
+
+/// The default for `--max-input`; keyrings are far smaller than this.
+const DEFAULT_MAX_INPUT: u64 = 1024 * 1024;
+
 impl IoArgs {
     pub async fn get(
         self,
         stdin: impl 'static + AsyncRead,
         stdout: impl 'static + AsyncWrite,
     ) -> std::io::Result<(Box<dyn AsyncRead>, Box<dyn AsyncWrite>)> {
-        let input: Box<dyn AsyncRead> = if let Some(in_path) = self.input {
-            Box::new(tokio::fs::File::open(in_path).await?)
+        let input: Pin<Box<dyn AsyncRead>> = if let Some(in_path) = self.input {
+            Box::pin(tokio::fs::File::open(in_path).await?)
         } else {
-            Box::new(stdin)
+            Box::pin(stdin)
         };
+        let input: Box<dyn AsyncRead> = Box::new(LimitedReader {
+            inner: input,
+            remaining: self.max_input,
+        });
 
         let output: Box<dyn AsyncWrite> = if let Some(out_path) = self.output {
-            Box::new(tokio::fs::File::create(out_path).await?)
+            Box::new(create_output_file(&out_path, self.force).await?)
         } else {
             Box::new(stdout)
         };
@@ -367,6 +392,80 @@ impl IoArgs {
     }
 }
 
+/// Fails with [`std::io::ErrorKind::InvalidData`] once more than `remaining`
+/// bytes have been read from `inner`.
+struct LimitedReader {
+    inner: Pin<Box<dyn AsyncRead>>,
+    remaining: u64,
+}
+
+impl AsyncRead for LimitedReader {
+    fn poll_read(
+        mut self: Pin<&mut Self>,
+        cx: &mut Context<'_>,
+        buf: &mut ReadBuf<'_>,
+    ) -> Poll<std::io::Result<()>> {
+        let filled = buf.filled().len();
+        ready!(self.inner.as_mut().poll_read(cx, buf))?;
+        let read = (buf.filled().len() - filled) as u64;
+        if read > self.remaining {
+            return Poll::Ready(Err(std::io::Error::new(
+                std::io::ErrorKind::InvalidData,
+                "input exceeds --max-input",
+            )));
+        }
+        self.remaining -= read;
+        Poll::Ready(Ok(()))
+    }
+}
+
+/// Opens `path` for writing a keyring.
+///
+/// On unix, the file is created with 0600 permissions. Symlinks are followed
+/// and the file they point to is checked. Existing regular files which are
+/// world-accessible are refused unless `force` is set. Otherwise, any group or
+/// other bits are cleared so that the file is restricted to 0600 before it is
+/// truncated. The checks are made on the opened file rather than the path.
+/// Other files, such as devices, are written as they are.
+async fn create_output_file(path: &Path, force: bool) -> std::io::Result<tokio::fs::File> {
+    #[cfg(unix)]
+    {
+        use std::os::unix::fs::PermissionsExt;
+        const MODE: u32 = 0o600;
+        let file = tokio::fs::OpenOptions::new()
+            .write(true)
+            .create(true)
+            .mode(MODE)
+            .open(path)
+            .await?;
+        let metadata = file.metadata().await?;
+        if !metadata.is_file() {
+            return Ok(file);
+        }
+        let mode = metadata.permissions().mode();
+        if mode & 0o007 != 0 && !force {
+            return Err(std::io::Error::new(
+                std::io::ErrorKind::PermissionDenied,
+                format!(
+                    "{} is world-accessible; use --force to overwrite",
+                    path.display()
+                ),
+            ));
+        }
+        if mode & 0o077 != 0 {
+            file.set_permissions(std::fs::Permissions::from_mode(MODE))
+                .await?;
+        }
+        file.set_len(0).await?;
+        Ok(file)
+    }
+    #[cfg(not(unix))]
+    {
+        let _ = force;
+        tokio::fs::File::create(path).await
+    }
+}
+
 #[derive(Debug, Parser)]
 pub struct EnvelopeArgs {
     #[arg(value_name = "KMS_KEY_URI", long = "kms-key-uri", short = 'k')]
@@ -435,3 +534,122 @@ impl EnvelopeArgs {
             .transpose()
     }
 }
+
+#[cfg(test)]
+mod tests {
+    use super::*;
+    use tokio::io::AsyncReadExt;
+
+    const LIMIT: u64 = 16;
+
+    async fn read_limited(len: usize) -> std::io::Result<Vec<u8>> {
+        let mut reader = LimitedReader {
+            inner: Box::pin(std::io::Cursor::new(vec![7u8; len])),
+            remaining: LIMIT,
+        };
+        let mut buf = Vec::new();
+        reader.read_to_end(&mut buf).await?;
+        Ok(buf)
+    }
+
+    #[tokio::test]
+    async fn test_limited_reader_under_limit() {
+        let buf = read_limited(LIMIT as usize - 1).await.unwrap();
+        assert_eq!(buf.len(), LIMIT as usize - 1);
+    }
+
+    #[tokio::test]
+    async fn test_limited_reader_at_limit() {
+        let buf = read_limited(LIMIT as usize).await.unwrap();
+        assert_eq!(buf.len(), LIMIT as usize);
+    }
+
+    #[tokio::test]
+    async fn test_limited_reader_over_limit() {
+        let err = read_limited(LIMIT as usize + 1).await.unwrap_err();
+        assert_eq!(err.kind(), std::io::ErrorKind::InvalidData);
+    }
+
+    #[cfg(unix)]
+    fn temp_path(name: &str) -> PathBuf {
+        let path = std::env::temp_dir().join(format!("navajo-cli-{}-{name}", std::process::id()));
+        let _ = std::fs::remove_file(&path);
+        path
+    }
+
+    #[cfg(unix)]
+    fn mode(path: &Path) -> u32 {
+        use std::os::unix::fs::PermissionsExt;
+        std::fs::metadata(path).unwrap().permissions().mode() & 0o777
+    }
+
+    #[cfg(unix)]
+    #[tokio::test]
+    async fn test_create_output_file_mode() {
+        let path = temp_path("create");
+        create_output_file(&path, false).await.unwrap();
+        assert_eq!(mode(&path), 0o600);
+        std::fs::remove_file(&path).unwrap();
+    }
+
+    #[cfg(unix)]
+    #[tokio::test]
+    async fn test_create_output_file_world_readable() {
+        use std::os::unix::fs::PermissionsExt;
+        let path = temp_path("world-readable");
+        std::fs::write(&path, b"keyring").unwrap();
+        std::fs::set_permissions(&path, std::fs::Permissions::from_mode(0o644)).unwrap();
+
+        let err = create_output_file(&path, false).await.unwrap_err();
+        assert_eq!(err.kind(), std::io::ErrorKind::PermissionDenied);
+        assert_eq!(mode(&path), 0o644);
+        assert_eq!(std::fs::read(&path).unwrap(), b"keyring");
+
+        create_output_file(&path, true).await.unwrap();
+        assert_eq!(mode(&path), 0o600);
+        std::fs::remove_file(&path).unwrap();
+    }
+
+    #[cfg(unix)]
+    #[tokio::test]
+    async fn test_create_output_file_group_readable() {
+        use std::os::unix::fs::PermissionsExt;
+        let path = temp_path("group-readable");
+        std::fs::write(&path, b"keyring").unwrap();
+        std::fs::set_permissions(&path, std::fs::Permissions::from_mode(0o640)).unwrap();
+
+        create_output_file(&path, false).await.unwrap();
+        assert_eq!(mode(&path), 0o600);
+        assert!(std::fs::read(&path).unwrap().is_empty());
+        std::fs::remove_file(&path).unwrap();
+    }
+
+    #[cfg(unix)]
+    #[tokio::test]
+    async fn test_create_output_file_symlink() {
+        use std::os::unix::fs::PermissionsExt;
+        let target = temp_path("symlink-target");
+        let link = temp_path("symlink");
+        std::fs::write(&target, b"keyring").unwrap();
+        std::fs::set_permissions(&target, std::fs::Permissions::from_mode(0o644)).unwrap();
+        std::os::unix::fs::symlink(&target, &link).unwrap();
+
+        let err = create_output_file(&link, false).await.unwrap_err();
+        assert_eq!(err.kind(), std::io::ErrorKind::PermissionDenied);
+        assert_eq!(mode(&target), 0o644);
+        assert_eq!(std::fs::read(&target).unwrap(), b"keyring");
+
+        create_output_file(&link, true).await.unwrap();
+        assert_eq!(mode(&target), 0o600);
+        std::fs::remove_file(&link).unwrap();
+        std::fs::remove_file(&target).unwrap();
+    }
+
+    #[cfg(unix)]
+    #[tokio::test]
+    async fn test_create_output_file_device() {
+        create_output_file(Path::new("/dev/null"), false)
+            .await
+            .unwrap();
+    }
+}
@@ -10,13 +10,18 @@ mod method;
 mod nonce;
 mod seed;
 mod segment;
+pub(crate) mod self_test;
 mod size;
 mod stream;
+mod timestamp;
 mod try_stream;
 use crate::{
     envelope,
-    error::{EncryptError, KeyNotFoundError, RemoveKeyError},
-    keyring::Keyring,
+    error::{
+        DecryptError, EncryptError, IncompatibleKeyringError, KeyNotFoundError, RemoveKeyError,
+        RewrapError,
+    },
+    keyring::{Keyring, KEY_ID_LEN},
     rand::Rng,
     Aad, Buffer, Envelope, SystemRng,
 };
@@ -32,20 +37,29 @@ use zeroize::ZeroizeOnDrop;
 pub use algorithm::Algorithm;
 pub use ciphertext_info::CiphertextInfo;
 pub use decryptor::Decryptor;
-pub use encryptor::Encryptor;
+pub use encryptor::{Encryptor, ResumePoint};
 pub use key_info::AeadKeyInfo;
 pub use method::Method;
 pub use segment::Segment;
 pub use stream::{AeadStream, DecryptStream, EncryptStream};
+#[cfg(feature = "std")]
+pub use timestamp::SystemClock;
+pub use timestamp::{Clock, TimestampWindow, TIMESTAMP_LEN};
 pub use try_stream::{AeadTryStream, DecryptTryStream, EncryptTryStream};
 
+#[cfg(feature = "std")]
+mod aad_chunks;
 #[cfg(feature = "std")]
 mod reader;
 #[cfg(feature = "std")]
+mod seekable_reader;
+#[cfg(feature = "std")]
 mod writer;
 #[cfg(feature = "std")]
 pub use reader::DecryptReader;
 #[cfg(feature = "std")]
+pub use seekable_reader::SeekableDecryptReader;
+#[cfg(feature = "std")]
 pub use writer::EncryptWriter;
 
 // use cipher::{ciphers, ring_ciphers, Cipher};
@@ -69,7 +83,21 @@ impl Aead {
         *plaintext = result;
         Ok(())
     }
-    /// encrypt...
+    /// Encrypts `plaintext` with the primary key, returning a single blob.
+    ///
+    /// The nonce is not returned separately. It is generated per call and
+    /// embedded in the header prepended to the ciphertext:
+    ///
+    /// ```plaintext
+    /// || Method (1) || Key Id (4) || Nonce (variable) || Ciphertext || Tag ||
+    /// ```
+    ///
+    /// [`decrypt`](Aead::decrypt) reads the nonce from this header, so the
+    /// output can be passed to it as-is.
+    ///
+    /// `plaintext` may be empty, in which case the output consists of only
+    /// the header and tag and serves to authenticate `aad`. Decrypting it with
+    /// the same `aad` returns an empty plaintext; any other `aad` fails.
     pub fn encrypt<A, T>(&self, aad: Aad<A>, plaintext: T) -> Result<Vec<u8>, EncryptError>
     where
         A: AsRef<[u8]>,
@@ -83,6 +111,85 @@ impl Aead {
         Ok(result)
     }
 
+    /// Encrypts `plaintext` with the key identified by `key_id` rather than
+    /// the primary key.
+    ///
+    /// [`decrypt`](Aead::decrypt) locates the key by the id in the header, so
+    /// the output is decrypted the same way as that of
+    /// [`encrypt`](Aead::encrypt).
+    ///
+    /// # Errors
+    /// Errors if the key does not exist, is disabled, or is decrypt-only.
+    pub fn encrypt_with_key<A, T>(
+        &self,
+        key_id: impl Into<u32>,
+        aad: Aad<A>,
+        plaintext: T,
+    ) -> Result<Vec<u8>, EncryptError>
+    where
+        A: AsRef<[u8]>,
+        T: AsRef<[u8]>,
+    {
+        let encryptor = Encryptor::new_with_key(self, key_id, None, plaintext.as_ref().to_vec())?;
+        let result = encryptor
+            .finalize(aad)?
+            .next()
+            .ok_or(EncryptError::Unspecified)?;
+        Ok(result)
+    }
+
+    /// Decrypts `ciphertext` with whichever key produced it and encrypts the
+    /// plaintext again with the primary key, returning the new ciphertext.
+    ///
+    /// The [`Method`] of `ciphertext`, including its segment size, is
+    /// preserved. The plaintext never leaves this call.
+    ///
+    /// # Errors
+    /// Returns [`RewrapError::Decrypt`] with the error from
+    /// [`decrypt`](Aead::decrypt) if `ciphertext` cannot be decrypted.
+    pub fn rewrap<A, T>(&self, aad: Aad<A>, ciphertext: T) -> Result<Vec<u8>, RewrapError>
+    where
+        A: AsRef<[u8]>,
+        T: AsRef<[u8]>,
+    {
+        let ciphertext = ciphertext.as_ref();
+        let plaintext = self.decrypt(Aad(aad.as_ref()), ciphertext)?;
+        let segment = match Method::try_from(ciphertext[0]).map_err(DecryptError::from)? {
+            Method::Online => None,
+            Method::StreamingHmacSha256(segment) => Some(segment),
+        };
+        let encryptor = Encryptor::new(self, segment, plaintext);
+        Ok(encryptor.finalize(aad)?.flatten().collect())
+    }
+
+    /// Encrypts `plaintext` bound to `timestamp`, which may be a time or a
+    /// monotonic counter.
+    ///
+    /// The timestamp is prepended to the ciphertext as [`TIMESTAMP_LEN`]
+    /// big-endian bytes and is authenticated as part of the additional data,
+    /// along with a label which keeps the ciphertext from being opened by
+    /// [`decrypt`](Aead::decrypt).
+    /// Use [`decrypt_with_timestamp`](Aead::decrypt_with_timestamp) to
+    /// decrypt and reject timestamps outside of an allowed window.
+    pub fn encrypt_with_timestamp<A, T>(
+        &self,
+        aad: Aad<A>,
+        plaintext: T,
+        timestamp: u64,
+    ) -> Result<Vec<u8>, EncryptError>
+    where
+        A: AsRef<[u8]>,
+        T: AsRef<[u8]>,
+    {
+        let timestamp = timestamp.to_be_bytes();
+        let aad = timestamp::aad_with_timestamp(aad.as_ref(), &timestamp);
+        let ciphertext = self.encrypt(Aad(&aad), plaintext)?;
+        let mut result = Vec::with_capacity(TIMESTAMP_LEN + ciphertext.len());
+        result.extend_from_slice(&timestamp);
+        result.extend_from_slice(&ciphertext);
+        Ok(result)
+    }
+
     pub fn encrypt_stream<S, A>(
         &self,
         stream: S,
@@ -148,6 +255,105 @@ impl Aead {
         Ok(result)
     }
 
+    /// Returns [`CiphertextInfo`] parsed from the header of `ciphertext`
+    /// without decrypting it. No key is needed, so ciphertext from any
+    /// keyring can be described.
+    ///
+    /// The ciphertext is not authenticated, so the result should only be used
+    /// for diagnostics.
+    ///
+    /// # Errors
+    /// Errors if `ciphertext` is too short to contain a method and key id or
+    /// the method is unknown.
+    pub fn ciphertext_info<T>(ciphertext: T) -> Result<CiphertextInfo, DecryptError>
+    where
+        T: AsRef<[u8]>,
+    {
+        let ciphertext = ciphertext.as_ref();
+        let (key_id, _) = Self::strip_header(ciphertext)?;
+        let method = Method::try_from(ciphertext[0])?;
+        Ok(CiphertextInfo { key_id, method })
+    }
+
+    /// Returns the [`Algorithm`] of the key in this keyring which produced
+    /// `ciphertext`, without decrypting it.
+    ///
+    /// The ciphertext is not authenticated, so the result should only be used
+    /// for diagnostics.
+    ///
+    /// # Errors
+    /// Errors if `ciphertext` is too short to contain a header, the method is
+    /// unknown, or the key is not in the keyring.
+    pub fn ciphertext_algorithm<T>(&self, ciphertext: T) -> Result<Algorithm, DecryptError>
+    where
+        T: AsRef<[u8]>,
+    {
+        let ciphertext = ciphertext.as_ref();
+        let info = Self::ciphertext_info(ciphertext)?;
+        let algorithm = self.keyring.get(info.key_id)?.algorithm();
+        if ciphertext.len() < info.method.header_len(algorithm) {
+            return Err(DecryptError::Unspecified);
+        }
+        Ok(algorithm)
+    }
+
+    /// Splits the method byte and key id from the front of `ciphertext`,
+    /// returning the key id and the remaining bytes.
+    ///
+    /// For ciphertext produced with [`Method::Online`], the remainder is the
+    /// nonce followed by the ciphertext and tag, suitable for use with a raw
+    /// implementation of the key's algorithm.
+    ///
+    /// The ciphertext is not authenticated.
+    ///
+    /// # Errors
+    /// Errors if `ciphertext` is empty, the method is unknown, or
+    /// `ciphertext` is too short to contain a key id.
+    pub fn strip_header(ciphertext: &[u8]) -> Result<(u32, &[u8]), DecryptError> {
+        if ciphertext.is_empty() {
+            return Err(DecryptError::EmptyCiphertext);
+        }
+        Method::try_from(ciphertext[0])?;
+        if ciphertext.len() < Method::LEN + KEY_ID_LEN {
+            return Err(DecryptError::Unspecified);
+        }
+        let (header, rest) = ciphertext.split_at(Method::LEN + KEY_ID_LEN);
+        let key_id = u32::from_be_bytes(header[Method::LEN..].try_into().unwrap()); // safety: len checked above
+        Ok((key_id, rest))
+    }
+
+    /// Decrypts ciphertext produced by
+    /// [`encrypt_with_timestamp`](Aead::encrypt_with_timestamp), returning the
+    /// timestamp and plaintext.
+    ///
+    /// # Errors
+    /// Errors with [`DecryptError::TimestampOutsideWindow`] if the ciphertext
+    /// is authentic but its timestamp is not within `window`.
+    pub fn decrypt_with_timestamp<A, T, C>(
+        &self,
+        aad: Aad<A>,
+        ciphertext: T,
+        window: &TimestampWindow<C>,
+    ) -> Result<(u64, Vec<u8>), DecryptError>
+    where
+        A: AsRef<[u8]>,
+        T: AsRef<[u8]>,
+        C: Clock,
+    {
+        let ciphertext = ciphertext.as_ref();
+        if ciphertext.len() < TIMESTAMP_LEN {
+            return Err(DecryptError::Unspecified);
+        }
+        let (timestamp, ciphertext) = ciphertext.split_at(TIMESTAMP_LEN);
+        let aad = timestamp::aad_with_timestamp(aad.as_ref(), timestamp);
+        let plaintext = self.decrypt(Aad(&aad), ciphertext)?;
+        let timestamp = u64::from_be_bytes(timestamp.try_into().unwrap()); // safety: length checked above
+        if !window.contains(timestamp) {
+            return Err(DecryptError::TimestampOutsideWindow(timestamp));
+        }
+        Ok((timestamp, plaintext))
+    }
+
     pub fn decrypt_in_place<A, T>(
         &self,
         aad: Aad<A>,
@@ -204,6 +410,21 @@ impl Aead {
     {
         DecryptReader::new(reader, aad, self)
     }
+
+    /// Returns a [`SeekableDecryptReader`] which supports reading arbitrary
+    /// ranges of plaintext, decrypting only the segments which cover them.
+    #[cfg(feature = "std")]
+    pub fn decrypt_seekable_reader<R, A>(
+        &self,
+        reader: R,
+        aad: Aad<A>,
+    ) -> SeekableDecryptReader<R, A, &Self>
+    where
+        R: std::io::Read + std::io::Seek,
+        A: AsRef<[u8]>,
+    {
+        SeekableDecryptReader::new(reader, aad, self)
+    }
 }
 
 impl Aead {
@@ -222,7 +443,12 @@ impl Aead {
         G: Rng,
     {
         Self {
-            keyring: Keyring::new(rng, Material::new(algorithm), crate::Origin::Navajo, meta),
+            keyring: Keyring::new(
+                rng,
+                Material::new(rng, algorithm),
+                crate::Origin::Navajo,
+                meta,
+            ),
         }
     }
 
@@ -239,7 +465,7 @@ impl Aead {
     pub fn add_key(&mut self, algorithm: Algorithm, meta: Option<Value>) -> &mut Self {
         self.keyring.add(
             &SystemRng,
-            Material::new(algorithm),
+            Material::new(&SystemRng, algorithm),
             crate::Origin::Navajo,
             meta,
         );
@@ -251,13 +477,42 @@ impl Aead {
         self.keyring.primary().into()
     }
 
+    /// Returns the id of the primary key.
+    ///
+    /// This is equivalent to `primary_key().id` without constructing the
+    /// [`AeadKeyInfo`].
+    pub fn primary_key_id(&self) -> u32 {
+        self.keyring.primary().id()
+    }
+
+    /// Returns the number of bytes [`encrypt`](Aead::encrypt) adds to the
+    /// plaintext with the primary key.
+    ///
+    /// See [`Algorithm::overhead`] for streaming encryption.
+    pub fn overhead(&self) -> usize {
+        self.keyring.primary().algorithm().online_overhead()
+    }
+
     pub fn promote_key(
         &mut self,
         key_id: impl Into<u32>,
-    ) -> Result<AeadKeyInfo, crate::error::KeyNotFoundError> {
+    ) -> Result<AeadKeyInfo, crate::error::PromoteKeyError<Algorithm>> {
         self.keyring.promote(key_id).map(AeadKeyInfo::new)
     }
 
+    /// Sets the [`Usage`](crate::Usage) of a key. A
+    /// [`DecryptOnly`](crate::Usage::DecryptOnly) key can not be promoted to
+    /// primary.
+    pub fn update_key_usage(
+        &mut self,
+        key_id: impl Into<u32>,
+        usage: crate::Usage,
+    ) -> Result<AeadKeyInfo, crate::error::UpdateKeyUsageError<Algorithm>> {
+        self.keyring
+            .update_usage(key_id, usage)
+            .map(AeadKeyInfo::new)
+    }
+
     pub fn disable_key(
         &mut self,
         key_id: impl Into<u32>,
@@ -276,6 +531,10 @@ impl Aead {
         self.keyring.remove(key_id).map(|k| AeadKeyInfo::new(&k))
     }
 
+    /// Replaces the metadata of the key with `key_id`.
+    ///
+    /// Metadata, such as a map of labels, is serialized with the keyring but
+    /// is not bound to ciphertext.
     pub fn update_key_meta(
         &mut self,
         key_id: impl Into<u32>,
@@ -284,6 +543,16 @@ impl Aead {
         self.keyring.update_meta(key_id, meta).map(AeadKeyInfo::new)
     }
 
+    /// Checks whether the keys of `other` can be combined with this
+    /// keyring, i.e. no key id is shared by keys with different material.
+    ///
+    /// # Errors
+    /// Returns [`IncompatibleKeyringError::ConflictingKey`] for the first
+    /// conflicting key.
+    pub fn compatible_with(&self, other: &Aead) -> Result<(), IncompatibleKeyringError> {
+        self.keyring.compatible_with(&other.keyring)
+    }
+
     pub(crate) fn keyring(&self) -> &Keyring<Material> {
         &self.keyring
     }
@@ -364,13 +633,9 @@ mod tests {
         for algorithm in Algorithm::iter() {
             let src = plaintext.clone();
             let aead = Aead::new(algorithm, None);
-            let result = aead.encrypt_in_place(Aad(&aad), &mut plaintext);
-            if plaintext.is_empty() {
-                if result.is_ok() {
-                    return false;
-                } else {
-                    continue;
-                }
+            if let Err(e) = aead.encrypt_in_place(Aad(&aad), &mut plaintext) {
+                println!("{e:?}");
+                return false;
             }
             if let Err(e) = aead.decrypt_in_place(Aad(&aad), &mut plaintext) {
                 println!("{e:?}");
@@ -425,4 +690,492 @@ mod tests {
         let result = decryptor.finalize(aad).unwrap().next().unwrap();
         assert_eq!(result, msg);
     }
+
+    #[test]
+    fn test_empty_plaintext() {
+        for algorithm in Algorithm::iter() {
+            let aead = Aead::new(algorithm, None);
+            let ciphertext = aead.encrypt(Aad(b"aad"), b"").unwrap();
+            assert_eq!(
+                ciphertext.len(),
+                algorithm.online_header_len() + algorithm.tag_len()
+            );
+            assert!(aead.decrypt(Aad(b"aad"), &ciphertext).unwrap().is_empty());
+            assert!(aead.decrypt(Aad(b"other"), &ciphertext).is_err());
+        }
+    }
+
+    #[test]
+    fn test_encrypt_with_key() {
+        let mut aead = Aead::new(Algorithm::Aes256Gcm, None);
+        aead.add_key(Algorithm::ChaCha20Poly1305, None);
+        let secondary = aead.keys()[1].id;
+        let ciphertext = aead
+            .encrypt_with_key(secondary, Aad(b"aad"), b"hello world")
+            .unwrap();
+        assert_eq!(&ciphertext[1..5], &secondary.to_be_bytes()[..]);
+        assert_eq!(
+            aead.decrypt(Aad(b"aad"), &ciphertext).unwrap(),
+            b"hello world"
+        );
+
+        aead.disable_key(secondary).unwrap();
+        assert!(matches!(
+            aead.encrypt_with_key(secondary, Aad(b"aad"), b"hello world"),
+            Err(EncryptError::KeyDisabled(id)) if id == secondary
+        ));
+        assert!(matches!(
+            aead.encrypt_with_key(7u32, Aad(b"aad"), b"hello world"),
+            Err(EncryptError::KeyNotFound(_))
+        ));
+    }
+
+    #[test]
+    fn test_encrypt_prepends_nonce() {
+        for algorithm in Algorithm::iter() {
+            let aead = Aead::new(algorithm, None);
+            let msg = b"hello world";
+            let ciphertext = aead.encrypt(Aad::empty(), msg).unwrap();
+            assert_eq!(
+                ciphertext.len(),
+                algorithm.online_header_len() + msg.len() + algorithm.tag_len()
+            );
+            assert_eq!(ciphertext[0], u8::from(Method::Online));
+            assert_eq!(&ciphertext[1..5], &aead.primary_key().id.to_be_bytes()[..]);
+            let other = aead.encrypt(Aad::empty(), msg).unwrap();
+            let nonce_rng = 5..algorithm.online_header_len();
+            assert_ne!(ciphertext[nonce_rng.clone()], other[nonce_rng]);
+            let plaintext = aead.decrypt(Aad::empty(), &ciphertext).unwrap();
+            assert_eq!(plaintext, msg);
+        }
+    }
+
+    #[test]
+    fn test_rejects_invalid_key_length() {
+        for algorithm in Algorithm::iter() {
+            let aead = Aead::new(algorithm, None);
+            let id = aead.primary_key().id;
+            for len in [0, algorithm.key_len() - 1, algorithm.key_len() + 1] {
+                let mut value = serde_json::to_value(aead.keyring()).unwrap();
+                let bytes = crate::sensitive::Bytes::from(vec![7u8; len]);
+                value["keys"][0]["material"]["value"] = serde_json::to_value(bytes).unwrap();
+                let err = serde_json::from_value::<Keyring<Material>>(value).unwrap_err();
+                let msg = err.to_string();
+                assert!(msg.contains(&id.to_string()), "{msg}");
+                assert!(
+                    msg.contains(&format!("expected {} bytes", algorithm.key_len())),
+                    "{msg}"
+                );
+            }
+            let value = serde_json::to_value(aead.keyring()).unwrap();
+            assert!(serde_json::from_value::<Keyring<Material>>(value).is_ok());
+        }
+    }
+
+    #[test]
+    fn test_overhead() {
+        let rng = SystemRng::new();
+        for algorithm in Algorithm::iter() {
+            let aead = Aead::new(algorithm, None);
+            let ciphertext = aead.encrypt(Aad::empty(), b"hello world").unwrap();
+            assert_eq!(ciphertext.len() - 11, aead.overhead());
+
+            let segment = Segment::FourKilobytes;
+            let max = algorithm.max_online_plaintext_len(segment);
+            for len in [0, 100, max - 1, max, max + 1, 4096, 8192, 20000] {
+                let mut plaintext = vec![0u8; len];
+                rng.fill(&mut plaintext);
+                let mut encryptor = Encryptor::new(&aead, Some(segment), Vec::new());
+                encryptor.update(Aad::empty(), &plaintext).unwrap();
+                let ciphertext: Vec<u8> = encryptor
+                    .finalize(Aad::empty())
+                    .unwrap()
+                    .flatten()
+                    .collect();
+                assert_eq!(
+                    ciphertext.len() - len,
+                    algorithm.overhead(len, Some(segment)),
+                    "{algorithm} {len}"
+                );
+            }
+        }
+    }
+
+    #[test]
+    fn test_timestamp_window() {
+        use core::cell::Cell;
+        let now = Cell::new(1000u64);
+        let window = TimestampWindow::new(|| now.get(), 30);
+        let aead = Aead::new(Algorithm::ChaCha20Poly1305, None);
+        let ciphertext = aead
+            .encrypt_with_timestamp(Aad(b"aad"), b"hello world", 1000)
+            .unwrap();
+        assert_eq!(&ciphertext[..TIMESTAMP_LEN], &1000u64.to_be_bytes()[..]);
+
+        let (timestamp, plaintext) = aead
+            .decrypt_with_timestamp(Aad(b"aad"), &ciphertext, &window)
+            .unwrap();
+        assert_eq!(timestamp, 1000);
+        assert_eq!(plaintext, b"hello world");
+
+        now.set(1030);
+        assert!(aead
+            .decrypt_with_timestamp(Aad(b"aad"), &ciphertext, &window)
+            .is_ok());
+
+        // replayed after the window has passed
+        now.set(1031);
+        assert!(matches!(
+            aead.decrypt_with_timestamp(Aad(b"aad"), &ciphertext, &window),
+            Err(DecryptError::TimestampOutsideWindow(1000))
+        ));
+
+        // moving the timestamp into the window breaks authentication
+        let mut tampered = ciphertext.clone();
+        tampered[..TIMESTAMP_LEN].copy_from_slice(&1031u64.to_be_bytes());
+        assert!(matches!(
+            aead.decrypt_with_timestamp(Aad(b"aad"), &tampered, &window),
+            Err(DecryptError::Unspecified)
+        ));
+    }
+
+    #[test]
+    fn test_timestamp_domain_separation() {
+        let window = TimestampWindow::new(|| 1000u64, 30);
+        let aead = Aead::new(Algorithm::Aes256Gcm, None);
+        let timestamp = 1000u64.to_be_bytes();
+        let aad_with_timestamp = [&b"aad"[..], &timestamp[..]].concat();
+
+        // timestamped ciphertext does not open without the window check
+        let ciphertext = aead
+            .encrypt_with_timestamp(Aad(b"aad"), b"hello world", 1000)
+            .unwrap();
+        let (_, sealed) = ciphertext.split_at(TIMESTAMP_LEN);
+        assert!(aead.decrypt(Aad(&aad_with_timestamp), sealed).is_err());
+        assert!(aead.decrypt(Aad(b"aad"), sealed).is_err());
+
+        // nor does plain ciphertext whose AAD ends in a forged timestamp
+        let sealed = aead
+            .encrypt(Aad(&aad_with_timestamp), b"hello world")
+            .unwrap();
+        let forged = [&timestamp[..], &sealed[..]].concat();
+        assert!(matches!(
+            aead.decrypt_with_timestamp(Aad(b"aad"), &forged, &window),
+            Err(DecryptError::Unspecified)
+        ));
+    }
+
+    #[test]
+    fn test_decrypt_only_key() {
+        use crate::{
+            error::{PromoteKeyError, UpdateKeyUsageError},
+            Usage,
+        };
+        let mut aead = Aead::new(Algorithm::Aes256Gcm, None);
+        let original = aead.primary_key().id;
+        let ciphertext = aead.encrypt(Aad(b"aad"), b"hello world").unwrap();
+
+        assert!(matches!(
+            aead.update_key_usage(original, Usage::DecryptOnly),
+            Err(UpdateKeyUsageError::IsPrimaryKey(_))
+        ));
+
+        aead.add_key(Algorithm::Aes256Gcm, None);
+        let replacement = aead.keys()[1].id;
+        aead.promote_key(replacement).unwrap();
+        let info = aead.update_key_usage(original, Usage::DecryptOnly).unwrap();
+        assert_eq!(info.usage, Usage::DecryptOnly);
+
+        assert!(matches!(
+            aead.promote_key(original),
+            Err(PromoteKeyError::DecryptOnly(_))
+        ));
+        assert_eq!(aead.primary_key().id, replacement);
+        assert!(matches!(
+            aead.encrypt_with_key(original, Aad(b"aad"), b"hello world"),
+            Err(EncryptError::KeyDecryptOnly(id)) if id == original
+        ));
+        assert_eq!(
+            aead.decrypt(Aad(b"aad"), &ciphertext).unwrap(),
+            b"hello world"
+        );
+
+        // usage survives serialization
+        let value = serde_json::to_value(aead.keyring()).unwrap();
+        let keyring: Keyring<Material> = serde_json::from_value(value).unwrap();
+        let aead = Aead::from_keyring(keyring);
+        assert!(aead
+            .keys()
+            .iter()
+            .any(|k| k.id == original && k.usage == Usage::DecryptOnly));
+    }
+
+    #[test]
+    fn test_meta_labels() {
+        let mut aead = Aead::new(Algorithm::Aes256Gcm, None);
+        let key_id = aead.primary_key().id;
+        let ciphertext = aead.encrypt(Aad(b"aad"), b"hello world").unwrap();
+        let labels = serde_json::json!({ "region": "us", "purpose": "session" });
+        let info = aead.update_key_meta(key_id, Some(labels.clone())).unwrap();
+        assert_eq!(info.meta.as_deref(), Some(&labels));
+
+        let value = serde_json::to_value(aead.keyring()).unwrap();
+        let keyring: Keyring<Material> = serde_json::from_value(value).unwrap();
+        let restored = Aead::from_keyring(keyring);
+        assert_eq!(restored.primary_key().meta.as_deref(), Some(&labels));
+        assert_eq!(
+            restored.decrypt(Aad(b"aad"), &ciphertext).unwrap(),
+            b"hello world"
+        );
+
+        // metadata does not affect compatibility
+        let mut relabeled = restored.clone();
+        relabeled.update_key_meta(key_id, None).unwrap();
+        assert!(restored.compatible_with(&relabeled).is_ok());
+    }
+
+    #[test]
+    fn test_ciphertext_info() {
+        let aead = Aead::new(Algorithm::ChaCha20Poly1305, None);
+        let key_id = aead.primary_key().id;
+        let ciphertext = aead.encrypt(Aad::empty(), b"hello world").unwrap();
+        let info = Aead::ciphertext_info(&ciphertext).unwrap();
+        assert_eq!(
+            info,
+            CiphertextInfo {
+                key_id,
+                method: Method::Online,
+            }
+        );
+        assert_eq!(
+            aead.ciphertext_algorithm(&ciphertext).unwrap(),
+            Algorithm::ChaCha20Poly1305
+        );
+
+        let mut encryptor = Encryptor::new(&aead, Some(Segment::FourKilobytes), Vec::new());
+        encryptor.update(Aad::empty(), vec![7u8; 10000]).unwrap();
+        let ciphertext: Vec<u8> = encryptor
+            .finalize(Aad::empty())
+            .unwrap()
+            .flatten()
+            .collect();
+        let info = Aead::ciphertext_info(&ciphertext).unwrap();
+        assert_eq!(
+            info.method,
+            Method::StreamingHmacSha256(Segment::FourKilobytes)
+        );
+        assert_eq!(info.key_id, key_id);
+        assert!(aead.ciphertext_algorithm(&ciphertext[..10]).is_err());
+        let other = Aead::new(Algorithm::ChaCha20Poly1305, None);
+        assert!(matches!(
+            other.ciphertext_algorithm(&ciphertext),
+            Err(DecryptError::KeyNotFound(_))
+        ));
+    }
+
+    #[test]
+    fn test_ciphertext_info_other_keyring() {
+        let other = Aead::new(Algorithm::Aes128Gcm, None);
+        let ciphertext = other.encrypt(Aad(b"aad"), b"hello world").unwrap();
+        let aead = Aead::new(Algorithm::Aes256Gcm, None);
+        assert!(aead.decrypt(Aad(b"aad"), &ciphertext).is_err());
+        let info = Aead::ciphertext_info(&ciphertext).unwrap();
+        assert_eq!(info.key_id, other.primary_key_id());
+        assert_eq!(info.method, Method::Online);
+    }
+
+    #[test]
+    fn test_ciphertext_info_too_short() {
+        let aead = Aead::new(Algorithm::Aes256Gcm, None);
+        let ciphertext = aead.encrypt(Aad::empty(), b"hello world").unwrap();
+        assert!(matches!(
+            Aead::ciphertext_info(b""),
+            Err(DecryptError::EmptyCiphertext)
+        ));
+        for len in 1..Method::LEN + KEY_ID_LEN {
+            assert!(matches!(
+                Aead::ciphertext_info(&ciphertext[..len]),
+                Err(DecryptError::Unspecified)
+            ));
+        }
+        assert!(Aead::ciphertext_info(&ciphertext[..Method::LEN + KEY_ID_LEN]).is_ok());
+    }
+
+    #[test]
+    fn test_ciphertext_info_unknown_method() {
+        let aead = Aead::new(Algorithm::Aes256Gcm, None);
+        let mut ciphertext = aead.encrypt(Aad::empty(), b"hello world").unwrap();
+        ciphertext[0] = 9;
+        assert!(matches!(
+            Aead::ciphertext_info(&ciphertext),
+            Err(DecryptError::Unspecified)
+        ));
+        assert!(aead.ciphertext_algorithm(&ciphertext).is_err());
+    }
+
+    #[test]
+    fn test_strip_header() {
+        let aead = Aead::new(Algorithm::Aes256Gcm, None);
+        let key_id = aead.primary_key().id;
+        let ciphertext = aead.encrypt(Aad(b"aad"), b"hello world").unwrap();
+        let (id, rest) = Aead::strip_header(&ciphertext).unwrap();
+        assert_eq!(id, key_id);
+        assert_eq!(rest, &ciphertext[Method::LEN + KEY_ID_LEN..]);
+
+        // the remainder is nonce || ciphertext || tag
+        let algorithm = Algorithm::Aes256Gcm;
+        let (nonce, sealed) = rest.split_at(algorithm.nonce_len());
+        let key = aead.keyring.get(key_id).unwrap();
+        let cipher = super::cipher::Cipher::new(algorithm, key.material().bytes());
+        let nonce = super::nonce::SingleNonce::try_from(nonce).unwrap();
+        let mut buf = sealed.to_vec();
+        cipher.decrypt_in_place(nonce, b"aad", &mut buf).unwrap();
+        assert_eq!(buf, b"hello world");
+
+        assert!(matches!(
+            Aead::strip_header(b""),
+            Err(DecryptError::EmptyCiphertext)
+        ));
+        assert!(Aead::strip_header(&ciphertext[..3]).is_err());
+        assert!(Aead::strip_header(&[9u8, 0, 0, 0, 1]).is_err());
+        assert_eq!(
+            Aead::strip_header(&ciphertext[..Method::LEN + KEY_ID_LEN]).unwrap(),
+            (key_id, &[][..])
+        );
+    }
+
+    #[test]
+    fn test_primary_key_id() {
+        let mut aead = Aead::new(Algorithm::Aes256Gcm, None);
+        assert_eq!(aead.primary_key_id(), aead.primary_key().id);
+        let original = aead.primary_key_id();
+        aead.add_key(Algorithm::ChaCha20Poly1305, None);
+        assert_eq!(aead.primary_key_id(), original);
+        let id = aead.keys()[1].id;
+        aead.promote_key(id).unwrap();
+        assert_eq!(aead.primary_key_id(), id);
+    }
+
+    #[test]
+    fn test_rewrap() {
+        let mut aead = Aead::new(Algorithm::Aes256Gcm, None);
+        aead.add_key(Algorithm::XChaCha20Poly1305, None);
+        let secondary = aead.keys()[1].id;
+        let ciphertext = aead
+            .encrypt_with_key(secondary, Aad(b"aad"), b"hello world")
+            .unwrap();
+        assert_eq!(
+            Aead::ciphertext_info(&ciphertext).unwrap().key_id,
+            secondary
+        );
+
+        let rewrapped = aead.rewrap(Aad(b"aad"), &ciphertext).unwrap();
+        let info = Aead::ciphertext_info(&rewrapped).unwrap();
+        assert_eq!(info.key_id, aead.primary_key_id());
+        assert_eq!(info.method, Method::Online);
+        assert_eq!(
+            aead.decrypt(Aad(b"aad"), &rewrapped).unwrap(),
+            b"hello world"
+        );
+
+        // streaming ciphertext remains streaming
+        let mut plaintext = vec![0u8; 10000];
+        SystemRng::new().fill(&mut plaintext);
+        let mut encryptor =
+            Encryptor::new_with_key(&aead, secondary, Some(Segment::FourKilobytes), Vec::new())
+                .unwrap();
+        encryptor.update(Aad(b"aad"), &plaintext).unwrap();
+        let ciphertext: Vec<u8> = encryptor.finalize(Aad(b"aad")).unwrap().flatten().collect();
+        let rewrapped = aead.rewrap(Aad(b"aad"), &ciphertext).unwrap();
+        let info = Aead::ciphertext_info(&rewrapped).unwrap();
+        assert_eq!(info.key_id, aead.primary_key_id());
+        assert_eq!(
+            info.method,
+            Method::StreamingHmacSha256(Segment::FourKilobytes)
+        );
+        assert_eq!(aead.decrypt(Aad(b"aad"), &rewrapped).unwrap(), plaintext);
+
+        assert!(matches!(
+            aead.rewrap(Aad(b"other"), &ciphertext),
+            Err(RewrapError::Decrypt(DecryptError::Unspecified))
+        ));
+        let other = Aead::new(Algorithm::Aes256Gcm, None);
+        assert!(matches!(
+            other.rewrap(Aad(b"aad"), &ciphertext),
+            Err(RewrapError::Decrypt(DecryptError::KeyNotFound(_)))
+        ));
+    }
+
+    #[test]
+    fn test_compatible_with() {
+        let aead = Aead::new(Algorithm::Aes256Gcm, None);
+        let other = Aead::new(Algorithm::ChaCha20Poly1305, None);
+        assert!(aead.compatible_with(&other).is_ok());
+        assert!(aead.compatible_with(&aead.clone()).is_ok());
+
+        // the same key with a different status is compatible
+        let mut rotated = aead.clone();
+        rotated.add_key(Algorithm::Aes128Gcm, None);
+        let id = rotated.keys()[1].id;
+        rotated.promote_key(id).unwrap();
+        assert!(aead.compatible_with(&rotated).is_ok());
+
+        // the same id with different material is not
+        let mut value = serde_json::to_value(other.keyring()).unwrap();
+        value["keys"][0]["id"] = serde_json::json!(aead.primary_key_id());
+        let conflicting = Aead::from_keyring(serde_json::from_value(value).unwrap());
+        assert_eq!(
+            aead.compatible_with(&conflicting),
+            Err(IncompatibleKeyringError::ConflictingKey(
+                aead.primary_key_id()
+            ))
+        );
+    }
+
+    // Generated independently of navajo from the derivation documented on
+    // `Method::StreamingHmacSha256`. Changes to the streaming format must not
+    // break this.
+    #[cfg(feature = "std")]
+    const XCHACHA_GOLDEN: &[u8] =
+        include_bytes!("../testdata/aead/xchacha20poly1305_streaming.bin");
+
+    #[cfg(feature = "std")]
+    #[test]
+    fn test_xchacha20poly1305_streaming_golden() {
+        let key: Vec<u8> = (0x00..0x20).collect();
+        let salt: Vec<u8> = (0x20..0x40).collect();
+        let nonce_prefix: Vec<u8> = (0x40..0x53).collect();
+        let aad = Aad(b"navajo xchacha20-poly1305 streaming");
+        let plaintext: Vec<u8> = (0..5000).map(|i| (i % 251) as u8).collect();
+
+        let aead = Aead::new(Algorithm::XChaCha20Poly1305, None);
+        let mut value = serde_json::to_value(aead.keyring()).unwrap();
+        value["keys"][0]["id"] = serde_json::json!(0x0a0b0c0du32);
+        value["keys"][0]["material"]["value"] =
+            serde_json::to_value(crate::sensitive::Bytes::from(key)).unwrap();
+        let aead = Aead::from_keyring(serde_json::from_value(value).unwrap());
+
+        assert_eq!(
+            aead.decrypt(Aad(aad.as_ref()), XCHACHA_GOLDEN).unwrap(),
+            plaintext
+        );
+
+        let rng = crate::rand::MockRandom::new();
+        rng.lock()
+            .expect_fill()
+            .times(2)
+            .returning(move |dst: &mut [u8]| {
+                match dst.len() {
+                    19 => dst.copy_from_slice(&nonce_prefix),
+                    32 => dst.copy_from_slice(&salt),
+                    len => panic!("unexpected fill of {len} bytes"),
+                }
+                Ok(())
+            });
+        let mut encryptor =
+            Encryptor::new_with_rng(rng, &aead, Some(Segment::FourKilobytes), Vec::new());
+        encryptor.update(Aad(aad.as_ref()), &plaintext).unwrap();
+        let ciphertext: Vec<u8> = encryptor.finalize(aad).unwrap().flatten().collect();
+        assert_eq!(ciphertext, XCHACHA_GOLDEN);
+    }
 }
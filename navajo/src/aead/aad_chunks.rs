@@ -0,0 +1,42 @@
+/// AAD appended in chunks to that supplied on creation of an
+/// [`EncryptWriter`](super::EncryptWriter) or
+/// [`DecryptReader`](super::DecryptReader).
+///
+/// Chunks can only be added until the AAD is bound by the first write or
+/// read.
+#[derive(Debug, Default)]
+pub(super) struct AadChunks {
+    joined: Vec<u8>,
+    bound: bool,
+}
+
+impl AadChunks {
+    /// Appends `chunk` to `base` and any previous chunks.
+    pub(super) fn add(&mut self, base: &[u8], chunk: &[u8]) -> std::io::Result<()> {
+        if self.bound {
+            return Err(std::io::Error::new(
+                std::io::ErrorKind::InvalidInput,
+                "navajo: AAD cannot be added once data has been processed",
+            ));
+        }
+        if self.joined.is_empty() {
+            self.joined.extend_from_slice(base);
+        }
+        self.joined.extend_from_slice(chunk);
+        Ok(())
+    }
+
+    /// Prevents further chunks from being added.
+    pub(super) fn bind(&mut self) {
+        self.bound = true;
+    }
+
+    /// Returns the complete AAD.
+    pub(super) fn get<'a>(&'a self, base: &'a [u8]) -> &'a [u8] {
+        if self.joined.is_empty() {
+            base
+        } else {
+            &self.joined
+        }
+    }
+}
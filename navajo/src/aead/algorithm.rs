@@ -2,7 +2,7 @@ use crate::keyring::KEY_ID_LEN;
 
 use super::{
     size::{AES_128_GCM, AES_256_GCM, CHACHA20_POLY1305, XCHACHA20_POLY1305},
-    Method,
+    Method, Segment,
 };
 use serde::{Deserialize, Serialize};
 use strum::{Display, EnumIter, FromRepr, IntoStaticStr};
@@ -86,6 +86,42 @@ impl Algorithm {
     pub fn streaming_header_len(&self) -> usize {
         Method::LEN + KEY_ID_LEN + self.nonce_prefix_len() + self.key_len()
     }
+    /// Returns the number of bytes [`Method::Online`] encryption adds to the
+    /// plaintext (header and tag).
+    pub fn online_overhead(&self) -> usize {
+        self.online_header_len() + self.tag_len()
+    }
+    /// Returns the number of bytes streaming encryption adds to each segment,
+    /// not including the header.
+    pub fn streaming_segment_overhead(&self) -> usize {
+        self.tag_len()
+    }
+    /// Returns the length of the longest plaintext which
+    /// [`Encryptor`](super::Encryptor) encrypts with [`Method::Online`] when
+    /// given `segment`. Longer plaintext is streamed.
+    ///
+    /// Online ciphertext of this length may exceed `segment` by the tag, as
+    /// only the header must fit within it.
+    pub(super) fn max_online_plaintext_len(&self, segment: Segment) -> usize {
+        segment - self.online_header_len()
+    }
+    /// Returns the total number of bytes encryption adds to a plaintext of
+    /// `plaintext_len` bytes when encrypted with
+    /// [`Encryptor`](super::Encryptor) using `segment`.
+    pub fn overhead(&self, plaintext_len: usize, segment: Option<Segment>) -> usize {
+        let segment = match segment {
+            Some(segment) => segment,
+            None => return self.online_overhead(),
+        };
+        if plaintext_len <= self.max_online_plaintext_len(segment) {
+            return self.online_overhead();
+        }
+        let segment = segment.to_usize();
+        let first = segment - self.streaming_header_len() - self.tag_len();
+        let len = segment - self.tag_len();
+        let segment_count = 1 + (plaintext_len - first + len - 1) / len;
+        self.streaming_header_len() + segment_count * self.streaming_segment_overhead()
+    }
 }
 impl From<Algorithm> for u8 {
     fn from(alg: Algorithm) -> Self {
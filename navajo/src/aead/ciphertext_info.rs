@@ -1,11 +1,12 @@
 use serde::{Deserialize, Serialize};
 
-use super::{Algorithm, Method};
+use super::Method;
 
-/// Metadata for ciphertext.
+/// Metadata parsed from the header of ciphertext by
+/// [`Aead::ciphertext_info`](super::Aead::ciphertext_info).
 #[derive(Clone, Debug, PartialEq, Eq, Serialize, Deserialize)]
 pub struct CiphertextInfo {
+    /// The id of the key which produced the ciphertext.
     pub key_id: u32,
-    pub algorithm: Algorithm,
     pub method: Method,
 }
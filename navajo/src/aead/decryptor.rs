@@ -2,13 +2,7 @@ use super::{
     nonce::{NonceSequence, SingleNonce},
     Material,
 };
-use crate::{
-    error::DecryptError,
-    hkdf::{self, Salt},
-    key::Key,
-    rand::Rng,
-    Aad, Aead, Buffer, SystemRng,
-};
+use crate::{error::DecryptError, key::Key, rand::Rng, Aad, Aead, Buffer, SystemRng};
 #[cfg(not(feature = "std"))]
 use alloc::vec;
 #[cfg(not(feature = "std"))]
@@ -17,7 +11,7 @@ use alloc::vec::{IntoIter, Vec};
 #[cfg(feature = "std")]
 use std::vec::IntoIter;
 
-use super::{cipher::Cipher, nonce::Nonce, Algorithm, Method};
+use super::{cipher::Cipher, encryptor::derive_segment_key, nonce::Nonce, Algorithm, Method};
 
 pub struct Decryptor<C, B, G>
 where
@@ -328,13 +322,8 @@ where
             }
         }
     }
-    fn derive_key(&self, salt: &[u8], aad: &[u8]) -> Vec<u8> {
-        let key = self.key.as_ref().unwrap();
-        let salt = Salt::new(hkdf::Algorithm::Sha256, salt);
-        let prk = salt.extract(key.material().bytes());
-        let mut derived_key = vec![0u8; key.len()];
-        prk.expand(&[aad], &mut derived_key).unwrap(); // safety: key is always an appropriate length
-        derived_key
+    fn derive_key(&self, salt: &[u8], aad: &[u8]) -> crate::sensitive::Bytes {
+        derive_segment_key(self.key.as_ref().unwrap(), salt, aad)
     }
 
     pub fn counter(&self) -> u32 {
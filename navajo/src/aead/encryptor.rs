@@ -9,7 +9,7 @@ use crate::rand::Rng;
 use crate::SystemRng;
 use crate::{
     buffer::BufferZeroizer,
-    error::{EncryptError, UnspecifiedError},
+    error::{DecryptError, EncryptError, ResumeError, UnspecifiedError},
     hkdf,
     key::Key,
     sensitive, Aad, Aead, Buffer,
@@ -63,8 +63,124 @@ where
     where
         C: AsRef<Aead>,
     {
-        Self::create(SystemRng, cipher.as_ref(), segment, buf)
+        let key = cipher.as_ref().keyring.primary().clone();
+        Self::create(SystemRng, key, segment, buf)
     }
+    /// Creates an [`Encryptor`] which encrypts with the key identified by
+    /// `key_id` rather than the primary key.
+    ///
+    /// # Errors
+    /// Errors if the key does not exist, is disabled, or is decrypt-only.
+    pub fn new_with_key<C>(
+        cipher: C,
+        key_id: impl Into<u32>,
+        segment: Option<Segment>,
+        buf: B,
+    ) -> Result<Self, EncryptError>
+    where
+        C: AsRef<Aead>,
+    {
+        let key = cipher.as_ref().keyring.get(key_id)?;
+        if key.is_disabled() {
+            return Err(EncryptError::KeyDisabled(key.id()));
+        }
+        if key.usage().is_decrypt_only() {
+            return Err(EncryptError::KeyDecryptOnly(key.id()));
+        }
+        Ok(Self::create(SystemRng, key.clone(), segment, buf))
+    }
+
+    /// Resumes an interrupted streaming encryption from `partial`, the
+    /// ciphertext written before the interruption.
+    ///
+    /// Only whole segments of `partial` are kept. Each is authenticated
+    /// before encryption resumes, so tampered ciphertext is rejected rather
+    /// than extended. The header and segment tags make the ciphertext its own
+    /// record of progress; no separate manifest is needed.
+    ///
+    /// The returned [`ResumePoint`] gives the length of `partial` to keep,
+    /// to which the caller should truncate its output, and the length of
+    /// plaintext those bytes cover. Encryption continues by passing the
+    /// plaintext from that offset onward to [`update`](Self::update) and
+    /// then calling [`finalize`](Self::finalize). The result is identical to
+    /// that of an uninterrupted encryption.
+    ///
+    /// `aad` must be the same as that of the original encryption.
+    ///
+    /// # Errors
+    /// Errors if `partial` was not produced with
+    /// [`Method::StreamingHmacSha256`], does not contain a whole segment or
+    /// fails authentication, or if its key is disabled or decrypt-only.
+    pub fn resume<C, A>(
+        cipher: C,
+        aad: Aad<A>,
+        partial: &[u8],
+        buf: B,
+    ) -> Result<(Self, ResumePoint), ResumeError>
+    where
+        C: AsRef<Aead>,
+        A: AsRef<[u8]>,
+    {
+        if partial.is_empty() {
+            return Err(DecryptError::EmptyCiphertext.into());
+        }
+        let segment = match Method::try_from(partial[0]).map_err(DecryptError::from)? {
+            Method::StreamingHmacSha256(segment) => segment,
+            Method::Online => return Err(DecryptError::Unspecified.into()),
+        };
+        let (key_id, header) = Aead::strip_header(partial)?;
+        let key = cipher
+            .as_ref()
+            .keyring
+            .get(key_id)
+            .map_err(DecryptError::from)?;
+        if key.is_disabled() {
+            return Err(EncryptError::KeyDisabled(key.id()).into());
+        }
+        if key.usage().is_decrypt_only() {
+            return Err(EncryptError::KeyDecryptOnly(key.id()).into());
+        }
+        let algorithm = key.algorithm();
+        let segment_len = segment.to_usize();
+        let completed = partial.len() / segment_len;
+        if completed == 0 {
+            return Err(DecryptError::Unspecified.into());
+        }
+        let (salt, header) = header.split_at(algorithm.key_len());
+        let nonce_prefix = &header[..algorithm.nonce_prefix_len()];
+        let backend = Cipher::new(algorithm, &derive_segment_key(key, salt, aad.as_ref()));
+        let mut nonce_seq = NonceSequence::new_with_prefix(algorithm.nonce_len(), nonce_prefix)
+            .map_err(DecryptError::from)?;
+
+        let header_len = algorithm.streaming_header_len();
+        let kept = &partial[..completed * segment_len];
+        for (idx, seg) in kept.chunks(segment_len).enumerate() {
+            let seg = if idx == 0 { &seg[header_len..] } else { seg };
+            let mut data = BufferZeroizer(seg.to_vec());
+            let nonce = nonce_seq.nth(idx as u32, false);
+            backend.decrypt_in_place(nonce, aad.as_ref(), &mut data)?;
+        }
+        nonce_seq.set_counter(completed as u32);
+
+        let mut encryptor = Self::create(SystemRng, key.clone(), Some(segment), buf);
+        encryptor.nonce_seq = Some(nonce_seq);
+        encryptor.cipher = Some(backend);
+        let point = ResumePoint {
+            ciphertext_len: kept.len(),
+            plaintext_len: kept.len() - header_len - completed * algorithm.tag_len(),
+        };
+        Ok((encryptor, point))
+    }
+}
+
+/// The position from which an interrupted encryption is resumed with
+/// [`Encryptor::resume`].
+#[derive(Clone, Copy, Debug, PartialEq, Eq)]
+pub struct ResumePoint {
+    /// The number of bytes of the partial ciphertext which were kept.
+    pub ciphertext_len: usize,
+    /// The number of bytes of plaintext covered by the kept ciphertext.
+    pub plaintext_len: usize,
 }
 impl<B, G> Encryptor<B, G>
 where
@@ -73,10 +189,9 @@ where
 {
     #[cfg(test)]
     pub fn new_with_rng(rand: G, aead: &Aead, segment: Option<Segment>, buf: B) -> Self {
-        Self::create(rand, aead, segment, buf)
+        Self::create(rand, aead.keyring.primary().clone(), segment, buf)
     }
-    fn create(rand: G, aead: &Aead, segment: Option<Segment>, buf: B) -> Self {
-        let key = aead.keyring.primary().clone();
+    fn create(rand: G, key: Key<Material>, segment: Option<Segment>, buf: B) -> Self {
         Self {
             key,
             segment,
@@ -141,6 +256,9 @@ where
     /// Finalizes the encryption and returns the remaining segments.
     ///
     /// Finalize **must** be called or the remaining segments will **not** be encrypted.
+    ///
+    /// An empty plaintext is valid and results in a single [`Method::Online`]
+    /// segment containing only the header and tag.
     #[must_use = "finalize must be called"]
     pub fn finalize<A>(mut self, aad: Aad<A>) -> Result<IntoIter<B>, EncryptError>
     where
@@ -148,16 +266,11 @@ where
     {
         if self.counter() == 0 {
             let buf_len = self.buffered_len();
-            if buf_len == 0 {
-                return Err(EncryptError::EmptyCleartext);
-            }
             if self.segment.is_none() {
                 return self.finalize_one_shot(aad.as_ref());
             }
             if let Some(segment) = self.segment {
-                if buf_len
-                    <= segment - (self.algorithm().online_header_len() + self.algorithm().tag_len())
-                {
+                if buf_len <= self.algorithm().max_online_plaintext_len(segment) {
                     return self.finalize_one_shot(aad.as_ref());
                 }
             }
@@ -190,7 +303,7 @@ where
     fn next_segment_rng(&self) -> Option<Range<usize>> {
         let segment = self.segment?;
         if self.counter() == 0 {
-            if self.buf.len() > segment - self.algorithm().online_header_len() {
+            if self.buf.len() > self.algorithm().max_online_plaintext_len(segment) {
                 Some(
                     0..segment
                         - self.algorithm().streaming_header_len()
@@ -253,11 +366,17 @@ where
 {
     let mut salt_bytes = vec![0u8; key.algorithm().key_len()];
     rng.fill(&mut salt_bytes).unwrap();
-    let salt = hkdf::Salt::new(hkdf::Algorithm::Sha256, &salt_bytes);
+    let derived_key = derive_segment_key(key, &salt_bytes, aad);
+    (salt_bytes, derived_key)
+}
+/// Derives the key for the segments of streaming ciphertext with
+/// HKDF-SHA256 from `key`, `salt` and `aad`.
+pub(super) fn derive_segment_key(key: &Key<Material>, salt: &[u8], aad: &[u8]) -> sensitive::Bytes {
+    let salt = hkdf::Salt::new(hkdf::Algorithm::Sha256, salt);
     let prk = salt.extract(key.material().bytes());
     let mut derived_key = vec![0u8; key.algorithm().key_len()];
     prk.expand(&[aad], &mut derived_key).unwrap(); // safety: key is always an appropriate length
-    (salt_bytes, sensitive::Bytes::from(derived_key))
+    sensitive::Bytes::from(derived_key)
 }
 
 #[cfg(test)]
@@ -267,6 +386,7 @@ mod tests {
 
     use crate::{
         aead::{segment::FOUR_KB, Algorithm, Method, Segment},
+        error::ResumeError,
         keyring::KEY_ID_LEN,
         Aad, Aead, SystemRng,
     };
@@ -394,4 +514,85 @@ mod tests {
             nonce_prefix
         );
     }
+
+    #[test]
+    fn test_resume() {
+        fn encrypt(aead: &Aead, data: &[u8]) -> Vec<u8> {
+            let mut encryptor = Encryptor::new(aead, Some(Segment::FourKilobytes), vec![]);
+            encryptor.update(Aad(b"aad"), data).unwrap();
+            encryptor.finalize(Aad(b"aad")).unwrap().flatten().collect()
+        }
+        let mut data = vec![0u8; 20000];
+        let rng = SystemRng::new();
+        rng.fill(&mut data);
+        let algorithm = Algorithm::ChaCha20Poly1305;
+        let aead = Aead::new(algorithm, None);
+        let ciphertext = encrypt(&aead, &data);
+
+        // interrupted partway through the third segment
+        let partial = &ciphertext[..FOUR_KB * 2 + 100];
+        let (mut encryptor, point) =
+            Encryptor::resume(&aead, Aad(b"aad"), partial, vec![]).unwrap();
+        assert_eq!(point.ciphertext_len, FOUR_KB * 2);
+        let mut resumed = partial[..point.ciphertext_len].to_vec();
+        encryptor
+            .update(Aad(b"aad"), &data[point.plaintext_len..])
+            .unwrap();
+        resumed.extend(encryptor.finalize(Aad(b"aad")).unwrap().flatten());
+        assert_eq!(resumed, ciphertext);
+        assert_eq!(aead.decrypt(Aad(b"aad"), &resumed).unwrap(), data);
+
+        // tampered segments are not resumed
+        let mut tampered = partial.to_vec();
+        tampered[FOUR_KB + 10] ^= 1;
+        assert!(matches!(
+            Encryptor::resume(&aead, Aad(b"aad"), &tampered, vec![]),
+            Err(ResumeError::Decrypt(_))
+        ));
+        assert!(Encryptor::resume(&aead, Aad(b"other"), partial, vec![]).is_err());
+        // less than a whole segment
+        assert!(Encryptor::resume(&aead, Aad(b"aad"), &ciphertext[..100], vec![]).is_err());
+        // a finished ciphertext ending on a whole final segment
+        let len = FOUR_KB * 2 - algorithm.streaming_header_len() - 2 * algorithm.tag_len();
+        let finished = encrypt(&aead, &data[..len]);
+        assert_eq!(finished.len(), FOUR_KB * 2);
+        assert!(Encryptor::resume(&aead, Aad(b"aad"), &finished, vec![]).is_err());
+
+        let online = aead.encrypt(Aad(b"aad"), b"hello world").unwrap();
+        assert!(Encryptor::resume(&aead, Aad(b"aad"), &online, vec![]).is_err());
+    }
+
+    #[test]
+    fn test_online_streaming_boundary() {
+        use strum::IntoEnumIterator;
+        let segment = Segment::FourKilobytes;
+        for algorithm in Algorithm::iter() {
+            let aead = Aead::new(algorithm, None);
+            let max = algorithm.max_online_plaintext_len(segment);
+            // plaintext between the previous threshold, which also left room
+            // for the tag, and the boundary used to fail with Unspecified
+
+            let previous = max - algorithm.tag_len();
+            for len in [previous, previous + 1, max - 1, max, max + 1] {
+                let mut plaintext = vec![0u8; len];
+                SystemRng::new().fill(&mut plaintext);
+                let mut encryptor = Encryptor::new(&aead, Some(segment), vec![]);
+                encryptor.update(Aad(b"aad"), &plaintext).unwrap();
+                let ciphertext: Vec<u8> =
+                    encryptor.finalize(Aad(b"aad")).unwrap().flatten().collect();
+                let expected = if len <= max {
+                    Method::Online
+                } else {
+                    Method::StreamingHmacSha256(segment)
+                };
+                assert_eq!(ciphertext[0], expected, "{algorithm} {len}");
+                assert_eq!(
+                    ciphertext.len() - len,
+                    algorithm.overhead(len, Some(segment)),
+                    "{algorithm} {len}"
+                );
+                assert_eq!(aead.decrypt(Aad(b"aad"), &ciphertext).unwrap(), plaintext);
+            }
+        }
+    }
 }
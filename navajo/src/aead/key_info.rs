@@ -1,6 +1,6 @@
 use alloc::sync::Arc;
 
-use crate::{key::Key, Status};
+use crate::{key::Key, Status, Usage};
 
 use super::Algorithm;
 
@@ -10,6 +10,7 @@ pub struct AeadKeyInfo {
     pub algorithm: Algorithm,
     pub status: Status,
     pub meta: Option<Arc<serde_json::Value>>,
+    pub usage: Usage,
 }
 
 impl AeadKeyInfo {
@@ -20,6 +21,7 @@ impl AeadKeyInfo {
             origin: key.origin(),
             status: key.status(),
             meta: key.meta(),
+            usage: key.usage(),
         }
     }
 }
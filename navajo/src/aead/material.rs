@@ -1,4 +1,4 @@
-use alloc::vec;
+use alloc::{format, vec};
 
 use serde::{Deserialize, Serialize};
 use zeroize::ZeroizeOnDrop;
@@ -7,7 +7,9 @@ use super::cipher::Cipher;
 use super::Algorithm;
 use crate::primitive::Kind;
 use crate::{
+    error::KeyError,
     key::{Key, KeyMaterial},
+    rand::{is_weak, Rng},
     sensitive::Bytes,
     Buffer,
 };
@@ -32,12 +34,30 @@ impl KeyMaterial for Material {
     fn kind() -> Kind {
         Kind::Aead
     }
+
+    fn validate(&self) -> Result<(), KeyError> {
+        let expected = self.algorithm.key_len();
+        if self.value.len() != expected {
+            return Err(KeyError(format!(
+                "invalid key length for {}; expected {expected} bytes, found {}",
+                self.algorithm,
+                self.value.len()
+            )));
+        }
+        Ok(())
+    }
 }
 impl Material {
-    pub(super) fn new(algorithm: Algorithm) -> Self {
-        let bytes = vec![0u8; algorithm.key_len()].into();
+    pub(super) fn new<G>(rng: &G, algorithm: Algorithm) -> Self
+    where
+        G: Rng,
+    {
+        let mut bytes = vec![0u8; algorithm.key_len()];
+        while is_weak(&bytes) {
+            rng.fill(&mut bytes).unwrap();
+        }
         Self {
-            value: bytes,
+            value: bytes.into(),
             algorithm,
         }
     }
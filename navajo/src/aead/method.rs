@@ -26,6 +26,25 @@ pub enum Method {
     /// ```
     /// where `Salt` is the length of the algorithm's key and `Nonce Prefix` is the length of the
     /// algorithm's nonce minus 4 bytes for the segment counter 1 byte for the last-block flag.
+    ///
+    /// Each stream has its own segment key and nonce prefix, both randomly
+    /// generated:
+    ///
+    /// ```plaintext
+    /// segment key   = HKDF-SHA256(salt = Salt, ikm = key, info = AAD, len = key length)
+    /// segment nonce = Nonce Prefix || Counter (4, big-endian) || Last-Block Flag (1)
+    /// ```
+    /// where `Counter` is the zero-based index of the segment and
+    /// `Last-Block Flag` is `1` for the final segment and `0` otherwise. Every
+    /// segment is sealed with the same AAD.
+    ///
+    /// The first segment, including the header, and every subsequent segment
+    /// but the last are exactly the segment size in length. The final segment
+    /// may be shorter.
+    ///
+    /// For XChaCha20-Poly1305, the 24 byte nonce leaves a 19 byte random
+    /// prefix, which is large enough that per-stream prefixes can be chosen at
+    /// random without concern for collisions.
     StreamingHmacSha256(Segment),
 }
 impl Method {
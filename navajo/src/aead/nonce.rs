@@ -197,7 +197,7 @@ impl NonceSequence {
             Self::TwentyFour(_, seed) => seed.as_mut_slice(),
         }
     }
-    fn set_counter(&mut self, value: u32) {
+    pub(crate) fn set_counter(&mut self, value: u32) {
         let len = self.len();
         self.seed_mut()[len - 5..len - 1].copy_from_slice(&value.to_be_bytes()[..]);
         match self {
@@ -231,6 +231,18 @@ impl NonceSequence {
         Ok(nonce)
     }
 
+    /// Returns the nonce for the segment at `counter` without advancing the
+    /// sequence. `last` sets the last-block flag.
+    pub(crate) fn nth(&self, counter: u32, last: bool) -> SingleNonce {
+        let mut seq = self.clone();
+        seq.set_counter(counter);
+        if last {
+            let len = seq.len();
+            seq.seed_mut()[len - 1] = 1;
+        }
+        seq.nonce()
+    }
+
     pub(crate) fn last(mut self) -> Result<SingleNonce, crate::error::SegmentLimitExceededError> {
         if self.counter() == u32::MAX {
             return Err(crate::error::SegmentLimitExceededError);
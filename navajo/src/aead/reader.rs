@@ -5,7 +5,7 @@ use alloc::collections::VecDeque;
 
 use crate::{error::DecryptError, rand::Rng, Aad, Aead, SystemRng};
 
-use super::Decryptor;
+use super::{aad_chunks::AadChunks, Decryptor};
 
 pub struct DecryptReader<R, A, C, G = SystemRng>
 where
@@ -17,6 +17,7 @@ where
     reader: R,
     _marker: PhantomData<C>,
     aad: Aad<A>,
+    aad_chunks: AadChunks,
     deserializer: Option<Decryptor<C, Vec<u8>, G>>,
     buffer: VecDeque<u8>,
     rng: G,
@@ -32,6 +33,7 @@ where
             reader,
             _marker: PhantomData,
             aad,
+            aad_chunks: AadChunks::default(),
             deserializer: Some(Decryptor::new(cipher, Vec::new())),
             buffer: VecDeque::new(),
             rng: SystemRng,
@@ -52,11 +54,22 @@ where
             reader,
             _marker: PhantomData,
             aad,
+            aad_chunks: AadChunks::default(),
             deserializer: Some(Decryptor::new_with_rng(rng.clone(), cipher, Vec::new())),
             buffer: VecDeque::new(),
             rng,
         }
     }
+    /// Appends `aad` to the additional authenticated data.
+    ///
+    /// The plaintext is the same as if the AAD supplied on creation and each
+    /// appended chunk had been concatenated and supplied on creation.
+    ///
+    /// # Errors
+    /// Errors if data has already been read.
+    pub fn add_aad(&mut self, aad: &[u8]) -> std::io::Result<()> {
+        self.aad_chunks.add(self.aad.as_ref(), aad)
+    }
     fn update(&mut self, mut ctr: usize, iter: impl Iterator<Item = u8>, buf: &mut [u8]) -> usize {
         for b in iter {
             if ctr < buf.len() {
@@ -76,6 +89,7 @@ where
     C: AsRef<Aead>,
 {
     fn read(&mut self, buf: &mut [u8]) -> std::io::Result<usize> {
+        self.aad_chunks.bind();
         let mut ctr = 0;
 
         if !self.buffer.is_empty() {
@@ -102,7 +116,7 @@ where
             if n == 0 {
                 return Ok(ctr);
             }
-            deserializer.update(Aad(self.aad.as_ref()), &method_byte)?;
+            deserializer.update(Aad(self.aad_chunks.get(self.aad.as_ref())), &method_byte)?;
             offset = 1;
         }
 
@@ -113,17 +127,19 @@ where
             crate::aead::Method::Online => {
                 let mut data: Vec<u8> = Vec::new();
                 self.reader.read_to_end(&mut data)?;
-                let result = deserializer.finalize(Aad(self.aad.as_ref()))?;
+                let result = deserializer.finalize(Aad(self.aad_chunks.get(self.aad.as_ref())))?;
                 Ok(self.update(ctr, result.flatten(), buf))
             }
             crate::aead::Method::StreamingHmacSha256(segment) => {
                 let mut data: Vec<u8> = vec![0u8; segment - offset];
                 let n = self.reader.read(&mut data)?;
-                deserializer.update(Aad(self.aad.as_ref()), &data[..n])?;
+                deserializer.update(Aad(self.aad_chunks.get(self.aad.as_ref())), &data[..n])?;
                 if n < segment - offset {
                     let result = self.update(
                         ctr,
-                        deserializer.finalize(Aad(self.aad.as_ref()))?.flatten(),
+                        deserializer
+                            .finalize(Aad(self.aad_chunks.get(self.aad.as_ref())))?
+                            .flatten(),
                         buf,
                     );
                     Ok(result)
@@ -131,7 +147,7 @@ where
                     let result = self.update(
                         ctr,
                         deserializer
-                            .next(Aad(self.aad.as_ref()))?
+                            .next(Aad(self.aad_chunks.get(self.aad.as_ref())))?
                             .unwrap()
                             .iter()
                             .cloned(),
@@ -172,4 +188,43 @@ mod tests {
         reader.read_to_end(&mut buf).unwrap();
         assert_eq!(data.len(), buf.len());
     }
+
+    #[test]
+    fn test_chunked_aad() {
+        use std::io::Write;
+
+        use crate::aead::EncryptWriter;
+
+        let mut data = vec![0u8; 10000];
+        SystemRng::new().fill(&mut data);
+        let aead = Aead::new(Algorithm::Aes256Gcm, None);
+
+        let mut ciphertext = Vec::new();
+        let mut writer =
+            EncryptWriter::new(&mut ciphertext, Segment::FourKilobytes, Aad(b"one"), &aead);
+        writer.add_aad(b"two").unwrap();
+        writer.add_aad(b"three").unwrap();
+        writer.write_all(&data).unwrap();
+        assert!(writer.add_aad(b"four").is_err());
+        writer.finalize().unwrap();
+
+        let mut buf = Vec::new();
+        DecryptReader::new(&ciphertext[..], Aad(b"onetwothree"), &aead)
+            .read_to_end(&mut buf)
+            .unwrap();
+        assert_eq!(buf, data);
+
+        let mut reader = DecryptReader::new(&ciphertext[..], Aad(b"on"), &aead);
+        reader.add_aad(b"etwo").unwrap();
+        reader.add_aad(b"three").unwrap();
+        let mut buf = Vec::new();
+        reader.read_to_end(&mut buf).unwrap();
+        assert_eq!(buf, data);
+        assert!(reader.add_aad(b"four").is_err());
+
+        let mut buf = Vec::new();
+        assert!(DecryptReader::new(&ciphertext[..], Aad(b"one"), &aead)
+            .read_to_end(&mut buf)
+            .is_err());
+    }
 }
@@ -0,0 +1,333 @@
+use std::io::{Read, Seek, SeekFrom};
+
+use crate::{
+    error::{DecryptError, SegmentLimitExceededError},
+    keyring::KEY_ID_LEN,
+    Aad, Aead,
+};
+
+use super::{cipher::Cipher, encryptor::derive_segment_key, nonce::NonceSequence, Method};
+
+/// A [`Read`] + [`Seek`] decryptor which decrypts only the segments covering
+/// the bytes read.
+///
+/// Segments are authenticated individually, so reading a range which
+/// includes a tampered segment fails while other ranges remain readable.
+///
+/// Ciphertext produced with [`Method::Online`] is not segmented and is
+/// decrypted in full on first read.
+pub struct SeekableDecryptReader<R, A, C>
+where
+    R: Read + Seek,
+    A: AsRef<[u8]>,
+    C: AsRef<Aead>,
+{
+    reader: R,
+    aad: Aad<A>,
+    cipher: C,
+    state: Option<State>,
+    pos: u64,
+    current: Option<(u32, Vec<u8>)>,
+}
+
+enum State {
+    Online(Vec<u8>),
+    Streaming(Streaming),
+}
+
+struct Streaming {
+    backend: Cipher,
+    nonce_seq: NonceSequence,
+    segment: u64,
+    header_len: u64,
+    tag_len: u64,
+    ciphertext_len: u64,
+    segment_count: u64,
+}
+
+impl Streaming {
+    fn plaintext_len(&self) -> u64 {
+        self.ciphertext_len - self.header_len - self.segment_count * self.tag_len
+    }
+    fn first_segment_len(&self) -> u64 {
+        self.segment - self.header_len - self.tag_len
+    }
+    /// Returns the index of the segment containing the plaintext `pos` and
+    /// the offset within it.
+    fn locate(&self, pos: u64) -> (u64, usize) {
+        let first = self.first_segment_len();
+        if pos < first {
+            return (0, pos as usize);
+        }
+        let pos = pos - first;
+        let len = self.segment - self.tag_len;
+        (1 + pos / len, (pos % len) as usize)
+    }
+    fn ciphertext_range(&self, idx: u64) -> (u64, u64) {
+        let start = if idx == 0 {
+            self.header_len
+        } else {
+            idx * self.segment
+        };
+        let end = ((idx + 1) * self.segment).min(self.ciphertext_len);
+        (start, end)
+    }
+}
+
+impl<R, A, C> SeekableDecryptReader<R, A, C>
+where
+    R: Read + Seek,
+    A: AsRef<[u8]>,
+    C: AsRef<Aead>,
+{
+    pub fn new(reader: R, aad: Aad<A>, cipher: C) -> Self {
+        Self {
+            reader,
+            aad,
+            cipher,
+            state: None,
+            pos: 0,
+            current: None,
+        }
+    }
+
+    /// Returns the length of the plaintext.
+    pub fn plaintext_len(&mut self) -> std::io::Result<u64> {
+        self.init()?;
+        Ok(match self.state.as_ref().unwrap() {
+            State::Online(plaintext) => plaintext.len() as u64,
+            State::Streaming(streaming) => streaming.plaintext_len(),
+        })
+    }
+
+    fn init(&mut self) -> std::io::Result<()> {
+        if self.state.is_some() {
+            return Ok(());
+        }
+        let ciphertext_len = self.reader.seek(SeekFrom::End(0))?;
+        self.reader.seek(SeekFrom::Start(0))?;
+        let mut method = [0u8; Method::LEN];
+        self.reader.read_exact(&mut method)?;
+        let method = Method::try_from(method[0]).map_err(DecryptError::from)?;
+        let segment = match method {
+            Method::Online => {
+                let mut ciphertext = method.to_be_bytes().to_vec();
+                self.reader.read_to_end(&mut ciphertext)?;
+                let plaintext = self
+                    .cipher
+                    .as_ref()
+                    .decrypt(Aad(self.aad.as_ref()), ciphertext)?;
+                self.state = Some(State::Online(plaintext));
+                return Ok(());
+            }
+            Method::StreamingHmacSha256(segment) => segment,
+        };
+        let mut key_id = [0u8; KEY_ID_LEN];
+        self.reader.read_exact(&mut key_id)?;
+        let key = self
+            .cipher
+            .as_ref()
+            .keyring
+            .get(u32::from_be_bytes(key_id))
+            .map_err(DecryptError::from)?;
+        let algorithm = key.algorithm();
+        let header_len = algorithm.streaming_header_len() as u64;
+        let tag_len = algorithm.tag_len() as u64;
+        if ciphertext_len <= header_len + tag_len {
+            return Err(DecryptError::Unspecified.into());
+        }
+
+        let mut salt = vec![0u8; algorithm.key_len()];
+        self.reader.read_exact(&mut salt)?;
+        let mut nonce_prefix = vec![0u8; algorithm.nonce_prefix_len()];
+        self.reader.read_exact(&mut nonce_prefix)?;
+
+        let derived_key = derive_segment_key(key, &salt, self.aad.as_ref());
+        let backend = Cipher::new(algorithm, &derived_key);
+        let nonce_seq = NonceSequence::new_with_prefix(algorithm.nonce_len(), &nonce_prefix)
+            .map_err(DecryptError::from)?;
+
+        let segment = segment.to_usize() as u64;
+        let segment_count = (ciphertext_len + segment - 1) / segment;
+        if segment_count > u32::MAX as u64 {
+            return Err(DecryptError::from(SegmentLimitExceededError).into());
+        }
+        self.state = Some(State::Streaming(Streaming {
+            backend,
+            nonce_seq,
+            segment,
+            header_len,
+            tag_len,
+            ciphertext_len,
+            segment_count,
+        }));
+        Ok(())
+    }
+
+    fn load_segment(&mut self, idx: u64) -> std::io::Result<()> {
+        let idx32 = idx as u32;
+        if matches!(self.current, Some((i, _)) if i == idx32) {
+            return Ok(());
+        }
+        self.current = None;
+        let streaming = match self.state.as_ref().unwrap() {
+            State::Streaming(streaming) => streaming,
+            State::Online(_) => return Err(DecryptError::Unspecified.into()),
+        };
+        let (start, end) = streaming.ciphertext_range(idx);
+        let mut data = vec![0u8; (end - start) as usize];
+        self.reader.seek(SeekFrom::Start(start))?;
+        self.reader.read_exact(&mut data)?;
+        let is_last = idx == streaming.segment_count - 1;
+        let nonce = streaming.nonce_seq.nth(idx32, is_last);
+        streaming
+            .backend
+            .decrypt_in_place(nonce, self.aad.as_ref(), &mut data)?;
+        self.current = Some((idx32, data));
+        Ok(())
+    }
+}
+
+impl<R, A, C> Read for SeekableDecryptReader<R, A, C>
+where
+    R: Read + Seek,
+    A: AsRef<[u8]>,
+    C: AsRef<Aead>,
+{
+    fn read(&mut self, buf: &mut [u8]) -> std::io::Result<usize> {
+        if buf.is_empty() {
+            return Ok(0);
+        }
+        self.init()?;
+        let pos = self.pos;
+        let (idx, offset) = match self.state.as_ref().unwrap() {
+            State::Online(plaintext) => {
+                if pos >= plaintext.len() as u64 {
+                    return Ok(0);
+                }
+                let plaintext = &plaintext[pos as usize..];
+                let n = plaintext.len().min(buf.len());
+                buf[..n].copy_from_slice(&plaintext[..n]);
+                self.pos += n as u64;
+                return Ok(n);
+            }
+            State::Streaming(streaming) => {
+                if pos >= streaming.plaintext_len() {
+                    return Ok(0);
+                }
+                streaming.locate(pos)
+            }
+        };
+        self.load_segment(idx)?;
+        let (_, data) = self.current.as_ref().unwrap();
+        let data = &data[offset..];
+        let n = data.len().min(buf.len());
+        buf[..n].copy_from_slice(&data[..n]);
+        self.pos += n as u64;
+        Ok(n)
+    }
+}
+
+impl<R, A, C> Seek for SeekableDecryptReader<R, A, C>
+where
+    R: Read + Seek,
+    A: AsRef<[u8]>,
+    C: AsRef<Aead>,
+{
+    fn seek(&mut self, pos: SeekFrom) -> std::io::Result<u64> {
+        let pos = match pos {
+            SeekFrom::Start(pos) => Some(pos),
+            SeekFrom::End(offset) => {
+                let len = self.plaintext_len()?;
+                len.checked_add_signed(offset)
+            }
+            SeekFrom::Current(offset) => self.pos.checked_add_signed(offset),
+        };
+        let pos = pos.ok_or_else(|| {
+            std::io::Error::new(
+                std::io::ErrorKind::InvalidInput,
+                "invalid seek to a negative or overflowing position",
+            )
+        })?;
+        self.pos = pos;
+        Ok(pos)
+    }
+}
+
+#[cfg(test)]
+mod tests {
+    use std::io::Cursor;
+
+    use crate::{
+        aead::{Algorithm, Encryptor, Segment},
+        SystemRng,
+    };
+
+    use super::*;
+
+    fn encrypt(aead: &Aead, data: &[u8]) -> Vec<u8> {
+        let mut encryptor = Encryptor::new(aead, Some(Segment::FourKilobytes), Vec::new());
+        encryptor.update(Aad(b"aad"), data).unwrap();
+        encryptor
+            .finalize(Aad(b"aad"))
+            .unwrap()
+            .flatten()
+            .collect::<Vec<u8>>()
+    }
+
+    #[test]
+    fn test_read_middle_range() {
+        let mut data = vec![0u8; 20000];
+        let rng = SystemRng::new();
+        rng.fill(&mut data);
+        let aead = Aead::new(Algorithm::Aes256Gcm, None);
+        let ciphertext = encrypt(&aead, &data);
+        let mut reader = SeekableDecryptReader::new(Cursor::new(ciphertext), Aad(b"aad"), &aead);
+        assert_eq!(reader.plaintext_len().unwrap(), data.len() as u64);
+
+        reader.seek(SeekFrom::Start(5000)).unwrap();
+        let mut buf = vec![0u8; 9000];
+        reader.read_exact(&mut buf).unwrap();
+        assert_eq!(buf, data[5000..14000]);
+
+        reader.seek(SeekFrom::End(-100)).unwrap();
+        let mut buf = Vec::new();
+        reader.read_to_end(&mut buf).unwrap();
+        assert_eq!(buf, data[data.len() - 100..]);
+
+        reader.seek(SeekFrom::Start(0)).unwrap();
+        let mut buf = Vec::new();
+        reader.read_to_end(&mut buf).unwrap();
+        assert_eq!(buf, data);
+    }
+
+    #[test]
+    fn test_tampered_segment() {
+        let mut data = vec![0u8; 20000];
+        let rng = SystemRng::new();
+        rng.fill(&mut data);
+        let aead = Aead::new(Algorithm::ChaCha20Poly1305, None);
+        let mut ciphertext = encrypt(&aead, &data);
+        // flip a byte in the third segment
+        ciphertext[4096 * 2 + 10] ^= 1;
+        let mut reader = SeekableDecryptReader::new(Cursor::new(ciphertext), Aad(b"aad"), &aead);
+
+        let mut buf = vec![0u8; 100];
+        reader.read_exact(&mut buf).unwrap();
+        assert_eq!(buf, data[..100]);
+
+        reader.seek(SeekFrom::Start(9000)).unwrap();
+        assert!(reader.read_exact(&mut buf).is_err());
+    }
+
+    #[test]
+    fn test_online() {
+        let aead = Aead::new(Algorithm::Aes128Gcm, None);
+        let ciphertext = aead.encrypt(Aad(b"aad"), b"hello world").unwrap();
+        let mut reader = SeekableDecryptReader::new(Cursor::new(ciphertext), Aad(b"aad"), &aead);
+        reader.seek(SeekFrom::Start(6)).unwrap();
+        let mut buf = Vec::new();
+        reader.read_to_end(&mut buf).unwrap();
+        assert_eq!(buf, b"world");
+    }
+}
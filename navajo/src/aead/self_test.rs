@@ -0,0 +1,35 @@
+use alloc::vec::Vec;
+
+use super::{cipher::Cipher, nonce::SingleNonce, Algorithm};
+
+/// Encrypts `plaintext` with `key` and `nonce`, without a navajo header,
+/// and checks the result against `ciphertext` (which includes the tag). The
+/// ciphertext is then decrypted and checked against `plaintext`.
+pub(crate) fn check(
+    algorithm: Algorithm,
+    key: &[u8],
+    nonce: &[u8],
+    aad: &[u8],
+    plaintext: &[u8],
+    ciphertext: &[u8],
+) -> bool {
+    if key.len() != algorithm.key_len() || nonce.len() != algorithm.nonce_len() {
+        return false;
+    }
+    let nonce = match SingleNonce::try_from(nonce) {
+        Ok(nonce) => nonce,
+        Err(_) => return false,
+    };
+    let cipher = Cipher::new(algorithm, key);
+    let mut buf: Vec<u8> = plaintext.to_vec();
+    if cipher
+        .encrypt_in_place(nonce.clone(), aad, &mut buf)
+        .is_err()
+    {
+        return false;
+    }
+    if buf != ciphertext {
+        return false;
+    }
+    cipher.decrypt_in_place(nonce, aad, &mut buf).is_ok() && buf == plaintext
+}
@@ -0,0 +1,78 @@
+/// Length of the big-endian timestamp which prefixes ciphertext produced by
+/// [`Aead::encrypt_with_timestamp`](super::Aead::encrypt_with_timestamp).
+pub const TIMESTAMP_LEN: usize = 8;
+
+/// A source of the current time (or counter) against which timestamps are
+/// checked.
+pub trait Clock {
+    fn now(&self) -> u64;
+}
+
+impl<F> Clock for F
+where
+    F: Fn() -> u64,
+{
+    fn now(&self) -> u64 {
+        self()
+    }
+}
+
+/// A [`Clock`] which returns the number of seconds since the Unix epoch.
+#[cfg(feature = "std")]
+#[derive(Clone, Copy, Debug, Default)]
+pub struct SystemClock;
+
+#[cfg(feature = "std")]
+impl Clock for SystemClock {
+    fn now(&self) -> u64 {
+        std::time::SystemTime::now()
+            .duration_since(std::time::UNIX_EPOCH)
+            .map(|d| d.as_secs())
+            .unwrap_or(0)
+    }
+}
+
+/// Accepts timestamps no further than `window` from the current time of
+/// `clock`, in either direction.
+///
+/// Units are whatever `clock` and the caller of
+/// [`Aead::encrypt_with_timestamp`](super::Aead::encrypt_with_timestamp)
+/// agree upon.
+#[derive(Clone, Debug)]
+pub struct TimestampWindow<C> {
+    clock: C,
+    window: u64,
+}
+
+impl<C> TimestampWindow<C>
+where
+    C: Clock,
+{
+    pub fn new(clock: C, window: u64) -> Self {
+        Self { clock, window }
+    }
+    pub fn window(&self) -> u64 {
+        self.window
+    }
+    /// Returns `true` if `timestamp` is within the window.
+    pub fn contains(&self, timestamp: u64) -> bool {
+        self.clock.now().abs_diff(timestamp) <= self.window
+    }
+}
+
+/// Domain separates the additional data of timestamped ciphertext from that
+/// of ciphertext produced by [`Aead::encrypt`](super::Aead::encrypt).
+const TIMESTAMP_AAD_LABEL: &[u8] = b"navajo-ts";
+
+/// Returns `label || len(aad) || aad || timestamp`, where `len(aad)` is 8
+/// big-endian bytes. The length prefix keeps a caller's AAD which happens to
+/// end in a timestamp from being mistaken for the timestamp.
+pub(super) fn aad_with_timestamp(aad: &[u8], timestamp: &[u8]) -> alloc::vec::Vec<u8> {
+    let mut result =
+        alloc::vec::Vec::with_capacity(TIMESTAMP_AAD_LABEL.len() + 8 + aad.len() + TIMESTAMP_LEN);
+    result.extend_from_slice(TIMESTAMP_AAD_LABEL);
+    result.extend_from_slice(&(aad.len() as u64).to_be_bytes());
+    result.extend_from_slice(aad);
+    result.extend_from_slice(timestamp);
+    result
+}
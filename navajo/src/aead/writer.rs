@@ -4,7 +4,7 @@ use std::io::Write;
 
 use crate::{Aad, Aead};
 
-use super::{Buffer, Encryptor, Segment};
+use super::{aad_chunks::AadChunks, Buffer, Encryptor, Segment};
 
 /// Implements [`std::io::Write`] for encrypting data. This type is used
 /// internally by [`Aead`] for the method
@@ -22,6 +22,7 @@ where
     encryptor: Encryptor<Vec<u8>>,
     writer: &'write mut W,
     aad: Aad<A>,
+    aad_chunks: AadChunks,
     counter: usize,
 }
 impl<'write, W, A> EncryptWriter<'write, W, A>
@@ -38,9 +39,21 @@ where
             encryptor,
             writer,
             aad,
+            aad_chunks: AadChunks::default(),
             counter: 0,
         }
     }
+
+    /// Appends `aad` to the additional authenticated data.
+    ///
+    /// The ciphertext is the same as if the AAD supplied on creation and
+    /// each appended chunk had been concatenated and supplied on creation.
+    ///
+    /// # Errors
+    /// Errors if data has already been written.
+    pub fn add_aad(&mut self, aad: &[u8]) -> Result<(), std::io::Error> {
+        self.aad_chunks.add(self.aad.as_ref(), aad)
+    }
 }
 impl<'write, W, D> EncryptWriter<'write, W, D>
 where
@@ -53,8 +66,12 @@ where
             writer,
             mut counter,
             aad,
+            aad_chunks,
         } = self;
-        let ciphertext: Vec<u8> = encryptor.finalize(aad)?.flatten().collect();
+        let ciphertext: Vec<u8> = encryptor
+            .finalize(Aad(aad_chunks.get(aad.as_ref())))?
+            .flatten()
+            .collect();
         writer.write_all(&ciphertext)?;
         counter += ciphertext.len();
         writer.flush()?;
@@ -67,7 +84,9 @@ where
     D: AsRef<[u8]>,
 {
     fn write(&mut self, buf: &[u8]) -> Result<usize, std::io::Error> {
-        self.encryptor.update(Aad(self.aad.as_ref()), buf)?;
+        self.aad_chunks.bind();
+        self.encryptor
+            .update(Aad(self.aad_chunks.get(self.aad.as_ref())), buf)?;
         if let Some(ciphertext) = self.encryptor.next() {
             self.writer.write_all(&ciphertext)?;
             self.counter += ciphertext.len();
@@ -231,6 +231,189 @@ impl envelope::sync::Envelope for CleartextJson {
         Ok(vec![])
     }
 }
+/// `MultiEnvelope` wraps the Data Encryption Key (DEK) with each of its
+/// envelopes so that a keyring sealed with it can be opened by any one of
+/// them, e.g. for key escrow or backup.
+///
+/// To open, create a `MultiEnvelope` with the envelope(s) available. Each
+/// envelope is tried against each wrapped DEK until one succeeds, so opening
+/// may make up to `sealed_with * available` calls to the underlying
+/// envelopes.
+#[derive(Debug, Clone)]
+pub struct MultiEnvelope<E> {
+    envelopes: Vec<E>,
+}
+
+impl<E> MultiEnvelope<E> {
+    pub fn new(envelopes: Vec<E>) -> Self {
+        Self { envelopes }
+    }
+    pub fn envelopes(&self) -> &[E] {
+        &self.envelopes
+    }
+}
+
+#[derive(Debug)]
+pub enum MultiEnvelopeError<E> {
+    /// The `MultiEnvelope` does not contain any envelopes.
+    Empty,
+    /// The wrapped DEKs are malformed.
+    Malformed,
+    /// An envelope failed to encrypt or, if decrypting, none of the envelopes
+    /// were able to decrypt a wrapped DEK. The last error encountered is
+    /// returned.
+    Envelope(E),
+}
+
+impl<E> core::fmt::Display for MultiEnvelopeError<E>
+where
+    E: core::fmt::Display,
+{
+    fn fmt(&self, f: &mut core::fmt::Formatter<'_>) -> core::fmt::Result {
+        match self {
+            Self::Empty => write!(f, "navajo: multi envelope contains no envelopes"),
+            Self::Malformed => write!(f, "navajo: malformed multi envelope data"),
+            Self::Envelope(e) => core::fmt::Display::fmt(e, f),
+        }
+    }
+}
+
+impl<E> Error for MultiEnvelopeError<E> where E: Error {}
+
+fn encode_wrapped_deks(wrapped: &[Vec<u8>]) -> Vec<u8> {
+    let mut result = Vec::new();
+    for dek in wrapped {
+        result.extend_from_slice(&(dek.len() as u32).to_be_bytes());
+        result.extend_from_slice(dek);
+    }
+    result
+}
+
+fn decode_wrapped_deks(mut data: &[u8]) -> Option<Vec<Vec<u8>>> {
+    let mut result = Vec::new();
+    while !data.is_empty() {
+        if data.len() < 4 {
+            return None;
+        }
+        let len = u32::from_be_bytes(data[..4].try_into().unwrap()) as usize; // safety: len checked above
+        data = &data[4..];
+        if data.len() < len {
+            return None;
+        }
+        result.push(data[..len].to_vec());
+        data = &data[len..];
+    }
+    if result.is_empty() {
+        return None;
+    }
+    Some(result)
+}
+
+impl<E> Envelope for MultiEnvelope<E>
+where
+    E: Envelope + Sync,
+{
+    type EncryptError = MultiEnvelopeError<E::EncryptError>;
+    type DecryptError = MultiEnvelopeError<E::DecryptError>;
+
+    fn encrypt_dek<A, P>(
+        &self,
+        aad: Aad<A>,
+        plaintext: P,
+    ) -> Pin<Box<dyn Future<Output = Result<Vec<u8>, Self::EncryptError>> + Send + '_>>
+    where
+        A: 'static + AsRef<[u8]> + Send + Sync,
+        P: 'static + AsRef<[u8]> + Send + Sync,
+    {
+        let aad = aad.as_ref().to_vec();
+        let plaintext = plaintext.as_ref().to_vec();
+        Box::pin(async move {
+            if self.envelopes.is_empty() {
+                return Err(MultiEnvelopeError::Empty);
+            }
+            let mut wrapped = Vec::with_capacity(self.envelopes.len());
+            for envelope in &self.envelopes {
+                let dek = envelope
+                    .encrypt_dek(Aad(aad.clone()), plaintext.clone())
+                    .await
+                    .map_err(MultiEnvelopeError::Envelope)?;
+                wrapped.push(dek);
+            }
+            Ok(encode_wrapped_deks(&wrapped))
+        })
+    }
+
+    fn decrypt_dek<A, C>(
+        &self,
+        aad: Aad<A>,
+        ciphertext: C,
+    ) -> Pin<Box<dyn Future<Output = Result<Vec<u8>, Self::DecryptError>> + Send + '_>>
+    where
+        A: 'static + AsRef<[u8]> + Send + Sync,
+        C: 'static + AsRef<[u8]> + Send + Sync,
+    {
+        let aad = aad.as_ref().to_vec();
+        let wrapped = decode_wrapped_deks(ciphertext.as_ref());
+        Box::pin(async move {
+            let wrapped = wrapped.ok_or(MultiEnvelopeError::Malformed)?;
+            let mut err = MultiEnvelopeError::Empty;
+            for envelope in &self.envelopes {
+                for dek in &wrapped {
+                    match envelope.decrypt_dek(Aad(aad.clone()), dek.clone()).await {
+                        Ok(dek) => return Ok(dek),
+                        Err(e) => err = MultiEnvelopeError::Envelope(e),
+                    }
+                }
+            }
+            Err(err)
+        })
+    }
+}
+
+impl<E> envelope::sync::Envelope for MultiEnvelope<E>
+where
+    E: envelope::sync::Envelope,
+{
+    type EncryptError = MultiEnvelopeError<E::EncryptError>;
+    type DecryptError = MultiEnvelopeError<E::DecryptError>;
+
+    fn encrypt_dek<A, P>(&self, aad: Aad<A>, plaintext: P) -> Result<Vec<u8>, Self::EncryptError>
+    where
+        A: AsRef<[u8]>,
+        P: AsRef<[u8]>,
+    {
+        if self.envelopes.is_empty() {
+            return Err(MultiEnvelopeError::Empty);
+        }
+        let wrapped = self
+            .envelopes
+            .iter()
+            .map(|envelope| envelope.encrypt_dek(Aad(aad.as_ref()), plaintext.as_ref()))
+            .collect::<Result<Vec<_>, _>>()
+            .map_err(MultiEnvelopeError::Envelope)?;
+        Ok(encode_wrapped_deks(&wrapped))
+    }
+
+    fn decrypt_dek<A, C>(&self, aad: Aad<A>, ciphertext: C) -> Result<Vec<u8>, Self::DecryptError>
+    where
+        A: AsRef<[u8]>,
+        C: AsRef<[u8]>,
+    {
+        let wrapped =
+            decode_wrapped_deks(ciphertext.as_ref()).ok_or(MultiEnvelopeError::Malformed)?;
+        let mut err = MultiEnvelopeError::Empty;
+        for envelope in &self.envelopes {
+            for dek in &wrapped {
+                match envelope.decrypt_dek(Aad(aad.as_ref()), dek) {
+                    Ok(dek) => return Ok(dek),
+                    Err(e) => err = MultiEnvelopeError::Envelope(e),
+                }
+            }
+        }
+        Err(err)
+    }
+}
+
 pub(crate) fn is_cleartext<'a, T: Any + 'a>(envelope: &T) -> bool {
     let envelope = envelope as &dyn Any;
     envelope.downcast_ref::<CleartextJson>().is_some()
@@ -277,8 +460,10 @@ mod tests {
         assert!(keys.is_array());
         let keys = keys.as_array().unwrap();
         assert_eq!(keys.len(), m_keys.len());
+        let ids: Vec<u64> = keys.iter().map(|k| k["id"].as_u64().unwrap()).collect();
+        assert!(ids.windows(2).all(|w| w[0] < w[1]));
 
-        for (i, k) in keys.iter().enumerate() {
+        for k in keys.iter() {
             assert!(k.is_object());
             let k = k.as_object().unwrap();
             let id = k.get("id");
@@ -286,7 +471,8 @@ mod tests {
             let id = id.unwrap();
             assert!(id.is_number());
             let id = id.as_u64().unwrap() as u32;
-            assert_eq!(id, m_keys[i].id);
+            // keys are serialized in order of id
+            let m_key = m_keys.iter().find(|mk| mk.id == id).unwrap();
             let material = k.get("material");
             assert!(material.is_some());
             let material = material.unwrap();
@@ -298,10 +484,52 @@ mod tests {
             let algorithm = algorithm.unwrap();
             assert!(algorithm.is_string());
             let algorithm = algorithm.as_str().unwrap();
-            assert_eq!(algorithm, m_keys[i].algorithm.to_string());
+            assert_eq!(algorithm, m_key.algorithm.to_string());
         }
         assert_eq!(value.get("kind").unwrap(), "MAC");
 
         let _v = Mac::open(Aad::empty(), result, &envelope).await.unwrap();
     }
+
+    #[cfg(all(feature = "std", feature = "mac", feature = "hmac", feature = "sha2"))]
+    #[tokio::test]
+    async fn test_multi_envelope() {
+        use crate::mac::{Algorithm, Mac};
+
+        let mac = Mac::new(Algorithm::Sha256, None);
+        let keks = vec![InMemory::new(), InMemory::new(), InMemory::new()];
+        let sealed = Mac::seal(&mac, Aad(b"aad"), &MultiEnvelope::new(keks.clone()))
+            .await
+            .unwrap();
+        for kek in keks {
+            let envelope = MultiEnvelope::new(vec![kek]);
+            let opened = Mac::open(Aad(b"aad"), sealed.clone(), &envelope)
+                .await
+                .unwrap();
+            assert_eq!(opened.primary_key(), mac.primary_key());
+        }
+        let wrong = MultiEnvelope::new(vec![InMemory::new()]);
+        assert!(Mac::open(Aad(b"aad"), sealed.clone(), &wrong)
+            .await
+            .is_err());
+        let empty = MultiEnvelope::<InMemory>::new(vec![]);
+        assert!(Mac::seal(&mac, Aad(b"aad"), &empty).await.is_err());
+    }
+
+    #[cfg(all(feature = "std", feature = "mac", feature = "hmac", feature = "sha2"))]
+    #[test]
+    fn test_multi_envelope_sync() {
+        use crate::mac::{Algorithm, Mac};
+
+        let mac = Mac::new(Algorithm::Sha256, None);
+        let keks = vec![InMemory::new(), InMemory::new(), InMemory::new()];
+        let sealed = Mac::seal_sync(&mac, Aad(b"aad"), &MultiEnvelope::new(keks.clone())).unwrap();
+        for kek in keks {
+            let envelope = MultiEnvelope::new(vec![kek]);
+            let opened = Mac::open_sync(Aad(b"aad"), &sealed, &envelope).unwrap();
+            assert_eq!(opened.primary_key(), mac.primary_key());
+        }
+        let wrong = MultiEnvelope::new(vec![InMemory::new()]);
+        assert!(Mac::open_sync(Aad(b"aad"), &sealed, &wrong).is_err());
+    }
 }
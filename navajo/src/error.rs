@@ -98,7 +98,12 @@ impl Error for SegmentLimitExceededError {}
 pub enum EncryptError {
     Unspecified,
     SegmentLimitExceeded,
-    EmptyCleartext,
+    /// The keyring does not contain the requested key
+    KeyNotFound(KeyNotFoundError),
+    /// The requested key is disabled and can not be used for encryption
+    KeyDisabled(u32),
+    /// The requested key is decrypt-only and can not be used for encryption
+    KeyDecryptOnly(u32),
 }
 impl Error for EncryptError {}
 
@@ -107,7 +112,9 @@ impl fmt::Display for EncryptError {
         match self {
             Self::Unspecified => fmt::Display::fmt(&UnspecifiedError, f),
             Self::SegmentLimitExceeded => fmt::Display::fmt(&SegmentLimitExceededError, f),
-            Self::EmptyCleartext => write!(f, "plaintext is empty"),
+            Self::KeyNotFound(e) => fmt::Display::fmt(e, f),
+            Self::KeyDisabled(id) => write!(f, "navajo: key {id} is disabled"),
+            Self::KeyDecryptOnly(id) => write!(f, "navajo: key {id} is decrypt-only"),
         }
     }
 }
@@ -118,6 +125,12 @@ impl From<aes_gcm::Error> for EncryptError {
     }
 }
 
+impl From<KeyNotFoundError> for EncryptError {
+    fn from(e: KeyNotFoundError) -> Self {
+        Self::KeyNotFound(e)
+    }
+}
+
 impl From<SegmentLimitExceededError> for EncryptError {
     fn from(_: SegmentLimitExceededError) -> Self {
         Self::SegmentLimitExceeded
@@ -186,6 +199,9 @@ pub enum DecryptError {
     KeyNotFound(KeyNotFoundError),
     SegmentLimitExceeded,
     EmptyCiphertext,
+    /// The ciphertext is authentic but its timestamp is outside of the
+    /// allowed window.
+    TimestampOutsideWindow(u64),
 }
 
 impl Error for DecryptError {}
@@ -225,6 +241,10 @@ impl fmt::Display for DecryptError {
             Self::KeyNotFound(e) => fmt::Display::fmt(e, f),
             Self::SegmentLimitExceeded => fmt::Display::fmt(&SegmentLimitExceededError, f),
             Self::EmptyCiphertext => write!(f, "navajo: ciphertext must not be empty"),
+            Self::TimestampOutsideWindow(timestamp) => write!(
+                f,
+                "navajo: ciphertext timestamp {timestamp} is outside of the allowed window"
+            ),
         }
     }
 }
@@ -275,6 +295,63 @@ where
     }
 }
 
+/// Returned from [`Aead::rewrap`](crate::Aead::rewrap).
+#[derive(Debug, Clone)]
+pub enum RewrapError {
+    /// The ciphertext could not be decrypted.
+    Decrypt(DecryptError),
+    /// The plaintext could not be encrypted with the primary key.
+    Encrypt(EncryptError),
+}
+impl Error for RewrapError {}
+impl fmt::Display for RewrapError {
+    fn fmt(&self, f: &mut fmt::Formatter<'_>) -> fmt::Result {
+        match self {
+            Self::Decrypt(e) => fmt::Display::fmt(e, f),
+            Self::Encrypt(e) => fmt::Display::fmt(e, f),
+        }
+    }
+}
+impl From<DecryptError> for RewrapError {
+    fn from(e: DecryptError) -> Self {
+        Self::Decrypt(e)
+    }
+}
+impl From<EncryptError> for RewrapError {
+    fn from(e: EncryptError) -> Self {
+        Self::Encrypt(e)
+    }
+}
+
+/// Returned from [`Encryptor::resume`](crate::aead::Encryptor::resume).
+#[derive(Debug, Clone)]
+pub enum ResumeError {
+    /// The partial ciphertext could not be authenticated or is not
+    /// resumable.
+    Decrypt(DecryptError),
+    /// The key of the partial ciphertext can not be used for encryption.
+    Encrypt(EncryptError),
+}
+impl Error for ResumeError {}
+impl fmt::Display for ResumeError {
+    fn fmt(&self, f: &mut fmt::Formatter<'_>) -> fmt::Result {
+        match self {
+            Self::Decrypt(e) => fmt::Display::fmt(e, f),
+            Self::Encrypt(e) => fmt::Display::fmt(e, f),
+        }
+    }
+}
+impl From<DecryptError> for ResumeError {
+    fn from(e: DecryptError) -> Self {
+        Self::Decrypt(e)
+    }
+}
+impl From<EncryptError> for ResumeError {
+    fn from(e: EncryptError) -> Self {
+        Self::Encrypt(e)
+    }
+}
+
 #[derive(Debug, Clone)]
 pub struct MalformedError(pub Cow<'static, str>);
 impl Error for MalformedError {}
@@ -558,6 +635,122 @@ impl<A> From<KeyNotFoundError> for DisableKeyError<A> {
 #[cfg(feature = "std")]
 impl<A> std::error::Error for DisableKeyError<A> where A: Debug {}
 
+#[cfg(any(
+    feature = "aead",
+    feature = "daead",
+    feature = "mac",
+    feature = "signature",
+))]
+#[derive(Debug, Clone)]
+pub enum PromoteKeyError<A> {
+    /// The key is [`Usage::DecryptOnly`](crate::Usage::DecryptOnly) and can
+    /// not be primary.
+    DecryptOnly(crate::KeyInfo<A>),
+    KeyNotFound(KeyNotFoundError),
+}
+#[cfg(any(
+    feature = "aead",
+    feature = "daead",
+    feature = "mac",
+    feature = "signature",
+))]
+impl<A> fmt::Display for PromoteKeyError<A> {
+    fn fmt(&self, f: &mut fmt::Formatter<'_>) -> fmt::Result {
+        match self {
+            Self::DecryptOnly(info) => write!(
+                f,
+                "navajo: key {} is decrypt-only and cannot be promoted",
+                info.id
+            ),
+            Self::KeyNotFound(e) => fmt::Display::fmt(e, f),
+        }
+    }
+}
+#[cfg(any(
+    feature = "aead",
+    feature = "daead",
+    feature = "mac",
+    feature = "signature",
+))]
+impl<A> From<KeyNotFoundError> for PromoteKeyError<A> {
+    fn from(e: KeyNotFoundError) -> Self {
+        Self::KeyNotFound(e)
+    }
+}
+
+#[cfg(feature = "std")]
+impl<A> std::error::Error for PromoteKeyError<A> where A: Debug {}
+
+#[cfg(any(
+    feature = "aead",
+    feature = "daead",
+    feature = "mac",
+    feature = "signature",
+))]
+#[derive(Debug, Clone)]
+pub enum UpdateKeyUsageError<A> {
+    /// The primary key can not be made
+    /// [`Usage::DecryptOnly`](crate::Usage::DecryptOnly).
+    IsPrimaryKey(crate::KeyInfo<A>),
+    KeyNotFound(KeyNotFoundError),
+}
+#[cfg(any(
+    feature = "aead",
+    feature = "daead",
+    feature = "mac",
+    feature = "signature",
+))]
+impl<A> fmt::Display for UpdateKeyUsageError<A> {
+    fn fmt(&self, f: &mut fmt::Formatter<'_>) -> fmt::Result {
+        match self {
+            Self::IsPrimaryKey(_) => write!(f, "navajo: primary key cannot be decrypt-only"),
+            Self::KeyNotFound(e) => fmt::Display::fmt(e, f),
+        }
+    }
+}
+#[cfg(any(
+    feature = "aead",
+    feature = "daead",
+    feature = "mac",
+    feature = "signature",
+))]
+impl<A> From<KeyNotFoundError> for UpdateKeyUsageError<A> {
+    fn from(e: KeyNotFoundError) -> Self {
+        Self::KeyNotFound(e)
+    }
+}
+
+#[cfg(feature = "std")]
+impl<A> std::error::Error for UpdateKeyUsageError<A> where A: Debug {}
+
+/// Returned from `compatible_with` when two keyrings cannot be combined.
+#[derive(Debug, Clone, PartialEq, Eq)]
+pub enum IncompatibleKeyringError {
+    /// The keyrings are of different primitives.
+    KindMismatch {
+        expected: crate::primitive::Kind,
+        found: crate::primitive::Kind,
+    },
+    /// Both keyrings contain a key with this id but the keys' material or
+    /// origin differ.
+    ConflictingKey(u32),
+}
+impl fmt::Display for IncompatibleKeyringError {
+    fn fmt(&self, f: &mut fmt::Formatter<'_>) -> fmt::Result {
+        match self {
+            Self::KindMismatch { expected, found } => write!(
+                f,
+                "navajo: incompatible keyrings; expected a {expected} keyring, found {found}"
+            ),
+            Self::ConflictingKey(id) => write!(
+                f,
+                "navajo: incompatible keyrings; key {id} differs between keyrings"
+            ),
+        }
+    }
+}
+impl Error for IncompatibleKeyringError {}
+
 pub enum VerifyStreamError<E> {
     Upstream(E),
     FailedVerification,
@@ -599,6 +792,17 @@ impl<E> From<MacVerificationError> for VerifyStreamError<E> {
     }
 }
 
+/// The context string for BLAKE3 `derive_key` was empty.
+#[derive(Clone, Copy, Debug)]
+pub struct EmptyContextError;
+impl Error for EmptyContextError {}
+
+impl Display for EmptyContextError {
+    fn fmt(&self, f: &mut core::fmt::Formatter<'_>) -> core::fmt::Result {
+        write!(f, "navajo: BLAKE3 derive_key context must not be empty")
+    }
+}
+
 #[derive(Clone, Debug)]
 pub struct InvalidLengthError;
 impl Error for InvalidLengthError {}
@@ -622,6 +826,20 @@ impl From<rust_crypto_hkdf::InvalidLength> for InvalidLengthError {
     }
 }
 
+/// Returned from [`self_test`](crate::self_test) when the output of an
+/// algorithm does not match its known answer.
+#[derive(Debug, Clone, PartialEq, Eq)]
+pub struct SelfTestError {
+    /// The name of the algorithm which failed.
+    pub algorithm: String,
+}
+impl Error for SelfTestError {}
+impl fmt::Display for SelfTestError {
+    fn fmt(&self, f: &mut fmt::Formatter<'_>) -> fmt::Result {
+        write!(f, "navajo: self-test failed for {}", self.algorithm)
+    }
+}
+
 pub struct KeyError(pub String);
 impl Error for KeyError {}
 impl fmt::Display for KeyError {
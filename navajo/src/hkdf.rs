@@ -2,10 +2,22 @@
 
 mod algorithm;
 mod prk;
+#[cfg(any(
+    feature = "ring",
+    all(feature = "sha2", feature = "hmac"),
+    all(feature = "sha3", feature = "hmac")
+))]
+mod reader;
 mod salt;
 
 pub use algorithm::Algorithm;
 pub use prk::Prk;
+#[cfg(any(
+    feature = "ring",
+    all(feature = "sha2", feature = "hmac"),
+    all(feature = "sha3", feature = "hmac")
+))]
+pub use reader::ExpandReader;
 pub use salt::Salt;
 
 #[cfg(test)]
@@ -62,4 +74,21 @@ mod tests {
             assert_eq!(okm[..], expected[..])
         }
     }
+
+    #[test]
+    fn test_sha512_224() {
+        use crate::hkdf::*;
+        let ikm = hex::decode("0b0b0b0b0b0b0b0b0b0b0b0b0b0b0b0b0b0b0b0b0b0b").unwrap();
+        let salt = hex::decode("000102030405060708090a0b0c").unwrap();
+        let info = hex::decode("f0f1f2f3f4f5f6f7f8f9").unwrap();
+        let expected = hex::decode(
+            "f8d956e152b0fba831bac400f1a5af54982b91db3d96ae21a75655eff1725f928e491c63f3aedb408296",
+        )
+        .unwrap();
+        let salt = Salt::new(Algorithm::Sha512_224, &salt);
+        let prk = salt.extract(&ikm);
+        let mut okm = [0u8; 42];
+        prk.expand(&[&info[..]], &mut okm).unwrap();
+        assert_eq!(okm[..], expected[..]);
+    }
 }
@@ -9,6 +9,9 @@ pub enum Algorithm {
     Sha384,
     #[cfg(any(feature = "ring", all(feature = "sha2", feature = "hmac")))]
     Sha512,
+    /// SHA-512/224 as defined in FIPS 180-4, with its own initial hash value.
+    #[cfg(all(feature = "sha2", feature = "hmac"))]
+    Sha512_224,
     #[cfg(all(feature = "sha3", feature = "hmac"))]
     Sha3_256,
     #[cfg(all(feature = "sha3", feature = "hmac"))]
@@ -29,6 +32,8 @@ impl Algorithm {
             Algorithm::Sha384 => 48,
             #[cfg(any(feature = "ring", all(feature = "sha2", feature = "hmac")))]
             Algorithm::Sha512 => 64,
+            #[cfg(all(feature = "sha2", feature = "hmac"))]
+            Algorithm::Sha512_224 => 28,
             #[cfg(all(feature = "sha3", feature = "hmac"))]
             Algorithm::Sha3_256 => 32,
             #[cfg(all(feature = "sha3", feature = "hmac"))]
@@ -1,13 +1,22 @@
+use zeroize::Zeroize;
+
 use crate::error::InvalidLengthError;
 
-use super::Algorithm;
+use super::{Algorithm, ExpandReader};
 
 /// Psuedo-random key
 #[derive(Clone, Debug)]
 pub struct Prk {
+    pub(super) algorithm: Algorithm,
     pub(super) inner: PrkInner,
 }
 
+impl Prk {
+    pub fn algorithm(&self) -> Algorithm {
+        self.algorithm
+    }
+}
+
 #[cfg(any(
     feature = "ring",
     all(feature = "sha2", feature = "hmac"),
@@ -17,7 +26,7 @@ impl Prk {
     pub fn expand(&self, info: &[&[u8]], out: &mut [u8]) -> Result<(), InvalidLengthError> {
         match &self.inner {
             #[cfg(feature = "ring")]
-            PrkInner::Ring(prk) => {
+            PrkInner::Ring(prk, _) => {
                 let len = Length(out.len());
                 let okm = prk.expand(info, len)?;
                 okm.fill(out)?;
@@ -26,6 +35,42 @@ impl Prk {
             PrkInner::RustCrypto(prk) => prk.expand(info, out),
         }
     }
+
+    /// Returns an [`ExpandReader`] which yields the output of HKDF-Expand
+    /// for `info` incrementally, up to the maximum of 255 times the output
+    /// length of the hash.
+    pub fn expand_reader(&self, info: &[&[u8]]) -> ExpandReader {
+        ExpandReader::new(self.clone(), info)
+    }
+
+    /// Computes `T(counter) = HMAC(PRK, prev || info || counter)`, the
+    /// block of HKDF-Expand output following `prev`, into `out`.
+    pub(super) fn expand_block(&self, prev: &[u8], info: &[u8], counter: u8, out: &mut [u8]) {
+        let counter = [counter];
+        let data = [prev, info, &counter[..]];
+        match &self.inner {
+            #[cfg(feature = "ring")]
+            PrkInner::Ring(_, key) => {
+                let mut ctx = ring::hmac::Context::with_key(key);
+                for d in data {
+                    ctx.update(d);
+                }
+                out.copy_from_slice(ctx.sign().as_ref());
+            }
+            PrkInner::RustCrypto(prk) => prk.expand_block(&data, out),
+        }
+    }
+}
+
+impl Zeroize for Prk {
+    fn zeroize(&mut self) {
+        match &mut self.inner {
+            // ring does not expose its keys to be zeroized
+            #[cfg(feature = "ring")]
+            PrkInner::Ring(..) => {}
+            PrkInner::RustCrypto(prk) => prk.zeroize(),
+        }
+    }
 }
 
 struct Length(usize);
@@ -39,7 +84,7 @@ impl ring::hkdf::KeyType for Length {
 #[derive(Clone, Debug)]
 pub(super) enum PrkInner {
     #[cfg(feature = "ring")]
-    Ring(ring::hkdf::Prk),
+    Ring(ring::hkdf::Prk, ring::hmac::Key),
     RustCrypto(RustCryptoPrk),
 }
 #[derive(Clone, Debug)]
@@ -50,6 +95,8 @@ pub(super) enum RustCryptoPrk {
     Sha384(hmac::digest::Output<hmac::Hmac<sha2::Sha384>>),
     #[cfg(all(not(feature = "ring"), feature = "sha2", feature = "hmac"))]
     Sha512(hmac::digest::Output<hmac::Hmac<sha2::Sha512>>),
+    #[cfg(all(feature = "sha2", feature = "hmac"))]
+    Sha512_224(hmac::digest::Output<hmac::Hmac<sha2::Sha512_224>>),
     #[cfg(all(feature = "sha3", feature = "hmac"))]
     Sha3_256(hmac::digest::Output<hmac::Hmac<sha3::Sha3_256>>),
     #[cfg(all(feature = "sha3", feature = "hmac"))]
@@ -78,6 +125,11 @@ impl RustCryptoPrk {
                 let hk = Hkdf::<sha2::Sha512>::from_prk(prk).unwrap();
                 hk.expand_multi_info(info, out)?;
             }
+            #[cfg(all(feature = "sha2", feature = "hmac"))]
+            RustCryptoPrk::Sha512_224(prk) => {
+                let hk = Hkdf::<sha2::Sha512_224>::from_prk(prk).unwrap();
+                hk.expand_multi_info(info, out)?;
+            }
             #[cfg(feature = "sha3")]
             RustCryptoPrk::Sha3_256(prk) => {
                 let hk = Hkdf::<sha3::Sha3_256>::from_prk(prk).unwrap();
@@ -102,6 +154,37 @@ impl RustCryptoPrk {
         Ok(())
     }
 
+    fn expand_block(&self, data: &[&[u8]], out: &mut [u8]) {
+        match self {
+            #[cfg(all(not(feature = "ring"), feature = "sha2", feature = "hmac"))]
+            RustCryptoPrk::Sha256(prk) => hmac_block::<hmac::Hmac<sha2::Sha256>>(prk, data, out),
+            #[cfg(all(not(feature = "ring"), feature = "sha2", feature = "hmac"))]
+            RustCryptoPrk::Sha384(prk) => hmac_block::<hmac::Hmac<sha2::Sha384>>(prk, data, out),
+            #[cfg(all(not(feature = "ring"), feature = "sha2", feature = "hmac"))]
+            RustCryptoPrk::Sha512(prk) => hmac_block::<hmac::Hmac<sha2::Sha512>>(prk, data, out),
+            #[cfg(all(feature = "sha2", feature = "hmac"))]
+            RustCryptoPrk::Sha512_224(prk) => {
+                hmac_block::<hmac::Hmac<sha2::Sha512_224>>(prk, data, out)
+            }
+            #[cfg(all(feature = "sha3", feature = "hmac"))]
+            RustCryptoPrk::Sha3_256(prk) => {
+                hmac_block::<hmac::Hmac<sha3::Sha3_256>>(prk, data, out)
+            }
+            #[cfg(all(feature = "sha3", feature = "hmac"))]
+            RustCryptoPrk::Sha3_224(prk) => {
+                hmac_block::<hmac::Hmac<sha3::Sha3_224>>(prk, data, out)
+            }
+            #[cfg(all(feature = "sha3", feature = "hmac"))]
+            RustCryptoPrk::Sha3_384(prk) => {
+                hmac_block::<hmac::Hmac<sha3::Sha3_384>>(prk, data, out)
+            }
+            #[cfg(all(feature = "sha3", feature = "hmac"))]
+            RustCryptoPrk::Sha3_512(prk) => {
+                hmac_block::<hmac::Hmac<sha3::Sha3_512>>(prk, data, out)
+            }
+        }
+    }
+
     #[cfg(any(
         feature = "ring",
         all(feature = "sha2", feature = "hmac"),
@@ -115,6 +198,8 @@ impl RustCryptoPrk {
             RustCryptoPrk::Sha384(_) => Algorithm::Sha384,
             #[cfg(all(not(feature = "ring"), feature = "sha2", feature = "hmac"))]
             RustCryptoPrk::Sha512(_) => Algorithm::Sha512,
+            #[cfg(all(feature = "sha2", feature = "hmac"))]
+            RustCryptoPrk::Sha512_224(_) => Algorithm::Sha512_224,
             #[cfg(all(feature = "sha3", feature = "hmac"))]
             RustCryptoPrk::Sha3_256(_) => Algorithm::Sha256,
             #[cfg(all(feature = "sha3", feature = "hmac"))]
@@ -126,3 +211,38 @@ impl RustCryptoPrk {
         }
     }
 }
+
+impl Zeroize for RustCryptoPrk {
+    fn zeroize(&mut self) {
+        match self {
+            #[cfg(all(not(feature = "ring"), feature = "sha2", feature = "hmac"))]
+            RustCryptoPrk::Sha256(prk) => prk.as_mut_slice().zeroize(),
+            #[cfg(all(not(feature = "ring"), feature = "sha2", feature = "hmac"))]
+            RustCryptoPrk::Sha384(prk) => prk.as_mut_slice().zeroize(),
+            #[cfg(all(not(feature = "ring"), feature = "sha2", feature = "hmac"))]
+            RustCryptoPrk::Sha512(prk) => prk.as_mut_slice().zeroize(),
+            #[cfg(all(feature = "sha2", feature = "hmac"))]
+            RustCryptoPrk::Sha512_224(prk) => prk.as_mut_slice().zeroize(),
+            #[cfg(all(feature = "sha3", feature = "hmac"))]
+            RustCryptoPrk::Sha3_256(prk) => prk.as_mut_slice().zeroize(),
+            #[cfg(all(feature = "sha3", feature = "hmac"))]
+            RustCryptoPrk::Sha3_224(prk) => prk.as_mut_slice().zeroize(),
+            #[cfg(all(feature = "sha3", feature = "hmac"))]
+            RustCryptoPrk::Sha3_384(prk) => prk.as_mut_slice().zeroize(),
+            #[cfg(all(feature = "sha3", feature = "hmac"))]
+            RustCryptoPrk::Sha3_512(prk) => prk.as_mut_slice().zeroize(),
+        }
+    }
+}
+
+#[cfg(all(feature = "hmac", any(feature = "sha2", feature = "sha3")))]
+fn hmac_block<M>(key: &[u8], data: &[&[u8]], out: &mut [u8])
+where
+    M: hmac::Mac + hmac::digest::KeyInit,
+{
+    let mut mac = <M as hmac::digest::KeyInit>::new_from_slice(key).unwrap(); // safety: HMAC accepts keys of any length
+    for d in data {
+        mac.update(d);
+    }
+    out.copy_from_slice(&mac.finalize().into_bytes());
+}
@@ -0,0 +1,162 @@
+use alloc::vec::Vec;
+use zeroize::{Zeroize, ZeroizeOnDrop};
+
+use crate::error::InvalidLengthError;
+
+use super::Prk;
+
+/// Yields the output of HKDF-Expand incrementally, e.g. to derive several
+/// subkeys from a single [`Prk`] and info.
+///
+/// Reading in pieces produces the same bytes as a single call to
+/// [`Prk::expand`] of the combined length. Output is limited to 255 times
+/// the output length of the hash.
+///
+/// Created with [`Prk::expand_reader`].
+#[derive(ZeroizeOnDrop)]
+pub struct ExpandReader {
+    prk: Prk,
+    #[zeroize(skip)]
+    info: Vec<u8>,
+    /// The current block of output, `T(counter)`.
+    block: Vec<u8>,
+    counter: u8,
+    pos: usize,
+}
+
+impl ExpandReader {
+    pub(super) fn new(prk: Prk, info: &[&[u8]]) -> Self {
+        Self {
+            prk,
+            info: info.concat(),
+            block: Vec::new(),
+            counter: 0,
+            pos: 0,
+        }
+    }
+
+    /// The maximum number of bytes which can be read.
+    pub fn max_len(&self) -> usize {
+        255 * self.prk.algorithm().output_len()
+    }
+
+    /// The number of bytes which can still be read.
+    pub fn remaining(&self) -> usize {
+        self.max_len() - self.pos
+    }
+
+    /// Fills `out` with the next `out.len()` bytes of output.
+    ///
+    /// # Errors
+    /// Errors if fewer than `out.len()` bytes remain, in which case nothing
+    /// is read.
+    pub fn fill(&mut self, out: &mut [u8]) -> Result<(), InvalidLengthError> {
+        if out.len() > self.remaining() {
+            return Err(InvalidLengthError);
+        }
+        let block_len = self.prk.algorithm().output_len();
+        let mut written = 0;
+        while written < out.len() {
+            if self.pos == self.counter as usize * block_len {
+                self.next_block(block_len);
+            }
+            let offset = self.pos - (self.counter as usize - 1) * block_len;
+            let n = (block_len - offset).min(out.len() - written);
+            out[written..written + n].copy_from_slice(&self.block[offset..offset + n]);
+            written += n;
+            self.pos += n;
+        }
+        Ok(())
+    }
+
+    /// Replaces the current block `T(i)` with `T(i + 1)`.
+    fn next_block(&mut self, block_len: usize) {
+        let mut next = alloc::vec![0u8; block_len];
+        self.counter += 1;
+        self.prk
+            .expand_block(&self.block, &self.info, self.counter, &mut next);
+        self.block.zeroize();
+        self.block = next;
+    }
+}
+
+#[cfg(feature = "std")]
+impl std::io::Read for ExpandReader {
+    /// Reads up to `buf.len()` bytes, returning `0` once the maximum output
+    /// length has been reached.
+    fn read(&mut self, buf: &mut [u8]) -> std::io::Result<usize> {
+        let n = buf.len().min(self.remaining());
+        self.fill(&mut buf[..n])
+            .map_err(|e| std::io::Error::new(std::io::ErrorKind::Other, e))?;
+        Ok(n)
+    }
+}
+
+#[cfg(test)]
+mod tests {
+    use alloc::vec;
+
+    use crate::hkdf::{Algorithm, Salt};
+
+    #[test]
+    fn test_subkeys_match_single_expand() {
+        let prk = Salt::new(Algorithm::Sha256, b"salt").extract(b"input key material");
+        let mut expected = vec![0u8; 100];
+        prk.expand(&[&b"info"[..]], &mut expected).unwrap();
+
+        let mut reader = prk.expand_reader(&[&b"in"[..], &b"fo"[..]]);
+        let mut a = [0u8; 16];
+        let mut b = [0u8; 32];
+        let mut c = [0u8; 52];
+        reader.fill(&mut a).unwrap();
+        reader.fill(&mut b).unwrap();
+        reader.fill(&mut c).unwrap();
+        assert_eq!(a[..], expected[..16]);
+        assert_eq!(b[..], expected[16..48]);
+        assert_eq!(c[..], expected[48..]);
+        assert_eq!(reader.remaining(), 255 * 32 - 100);
+    }
+
+    #[test]
+    fn test_limit() {
+        let prk = Salt::new(Algorithm::Sha256, b"salt").extract(b"input key material");
+        let mut reader = prk.expand_reader(&[&b"info"[..]]);
+        let mut out = vec![0u8; 255 * 32];
+        reader.fill(&mut out[..255 * 32 - 1]).unwrap();
+        assert!(reader.fill(&mut [0u8; 2]).is_err());
+        reader.fill(&mut [0u8; 1]).unwrap();
+        assert_eq!(reader.remaining(), 0);
+        assert!(reader.fill(&mut [0u8; 1]).is_err());
+    }
+
+    #[test]
+    fn test_small_reads() {
+        for algorithm in [Algorithm::Sha256, Algorithm::Sha512] {
+            let prk = Salt::new(algorithm, b"salt").extract(b"input key material");
+            let len = 3 * algorithm.output_len() + 7;
+            let mut expected = vec![0u8; len];
+            prk.expand(&[&b"info"[..]], &mut expected).unwrap();
+
+            let mut reader = prk.expand_reader(&[&b"info"[..]]);
+            let mut output = vec![0u8; len];
+            for b in output.chunks_mut(5) {
+                reader.fill(b).unwrap();
+            }
+            assert_eq!(output, expected);
+        }
+    }
+
+    #[cfg(feature = "std")]
+    #[test]
+    fn test_read_to_eof() {
+        use std::io::Read;
+        let prk = Salt::new(Algorithm::Sha384, b"salt").extract(b"input key material");
+        let mut expected = vec![0u8; 255 * 48];
+        prk.expand(&[&b"info"[..]], &mut expected).unwrap();
+        let mut output = vec![];
+        prk.expand_reader(&[&b"info"[..]])
+            .read_to_end(&mut output)
+            .unwrap();
+        assert_eq!(output, expected);
+    }
+}
@@ -68,21 +68,29 @@ enum SaltInner {
 #[derive(Clone)]
 #[cfg(feature = "ring")]
 struct RingSalt {
-    salt: Arc<ring::hkdf::Salt>,
+    salt: Arc<ring::hmac::Key>,
     algorithm: Algorithm,
 }
 #[cfg(feature = "ring")]
 impl RingSalt {
     fn new(algorithm: Algorithm, value: &[u8]) -> Self {
+        let hkdf: ring::hkdf::Algorithm = algorithm.into();
         Self {
-            salt: Arc::new(ring::hkdf::Salt::new(algorithm.into(), value)),
+            salt: Arc::new(ring::hmac::Key::new(hkdf.hmac_algorithm(), value)),
             algorithm,
         }
     }
     fn extract(&self, secret: &[u8]) -> Prk {
-        let prk = self.salt.extract(secret);
+        // HKDF-Extract is HMAC(salt, secret). ring's hkdf::Prk does not
+        // expose the PRK, so it is also kept as an HMAC key for ExpandReader.
+        let hkdf: ring::hkdf::Algorithm = self.algorithm.into();
+        let prk = ring::hmac::sign(&self.salt, secret);
         Prk {
-            inner: PrkInner::Ring(prk),
+            algorithm: self.algorithm,
+            inner: PrkInner::Ring(
+                ring::hkdf::Prk::new_less_safe(hkdf, prk.as_ref()),
+                ring::hmac::Key::new(hkdf.hmac_algorithm(), prk.as_ref()),
+            ),
         }
     }
 }
@@ -95,6 +103,8 @@ enum RustCryptoSalt {
     Sha384(hmac::Hmac<sha2::Sha384>),
     #[cfg(all(not(feature = "ring"), feature = "sha2", feature = "hmac"))]
     Sha512(hmac::Hmac<sha2::Sha512>),
+    #[cfg(all(feature = "sha2", feature = "hmac"))]
+    Sha512_224(hmac::Hmac<sha2::Sha512_224>),
     #[cfg(all(feature = "sha3", feature = "hmac"))]
     Sha3_256(hmac::Hmac<sha3::Sha3_256>),
     #[cfg(all(feature = "sha3", feature = "hmac"))]
@@ -119,6 +129,8 @@ impl RustCryptoSalt {
             Algorithm::Sha384 => Self::Sha384(hmac::Mac::new_from_slice(value).unwrap()),
             #[cfg(all(not(feature = "ring"), feature = "sha2", feature = "hmac"))]
             Algorithm::Sha512 => Self::Sha512(hmac::Mac::new_from_slice(value).unwrap()),
+            #[cfg(all(feature = "sha2", feature = "hmac"))]
+            Algorithm::Sha512_224 => Self::Sha512_224(hmac::Mac::new_from_slice(value).unwrap()),
             #[cfg(feature = "sha3")]
             Algorithm::Sha3_256 => Self::Sha3_256(hmac::Mac::new_from_slice(value).unwrap()),
             #[cfg(feature = "sha3")]
@@ -139,6 +151,7 @@ impl RustCryptoSalt {
                 let mut salt = salt.clone();
                 salt.update(secret);
                 Prk {
+                    algorithm: Algorithm::Sha256,
                     inner: PrkInner::RustCrypto(RustCryptoPrk::Sha256(
                         salt.finalize().into_bytes(),
                     )),
@@ -149,6 +162,7 @@ impl RustCryptoSalt {
                 let mut salt = salt.clone();
                 salt.update(secret);
                 Prk {
+                    algorithm: Algorithm::Sha384,
                     inner: PrkInner::RustCrypto(RustCryptoPrk::Sha384(
                         salt.finalize().into_bytes(),
                     )),
@@ -159,16 +173,29 @@ impl RustCryptoSalt {
                 let mut salt = salt.clone();
                 salt.update(secret);
                 Prk {
+                    algorithm: Algorithm::Sha512,
                     inner: PrkInner::RustCrypto(RustCryptoPrk::Sha512(
                         salt.finalize().into_bytes(),
                     )),
                 }
             }
+            #[cfg(all(feature = "sha2", feature = "hmac"))]
+            RustCryptoSalt::Sha512_224(salt) => {
+                let mut salt = salt.clone();
+                salt.update(secret);
+                Prk {
+                    algorithm: Algorithm::Sha512_224,
+                    inner: PrkInner::RustCrypto(RustCryptoPrk::Sha512_224(
+                        salt.finalize().into_bytes(),
+                    )),
+                }
+            }
             #[cfg(feature = "sha3")]
             RustCryptoSalt::Sha3_256(salt) => {
                 let mut salt = salt.clone();
                 salt.update(secret);
                 Prk {
+                    algorithm: Algorithm::Sha3_256,
                     inner: PrkInner::RustCrypto(RustCryptoPrk::Sha3_256(
                         salt.finalize().into_bytes(),
                     )),
@@ -179,6 +206,7 @@ impl RustCryptoSalt {
                 let mut salt = salt.clone();
                 salt.update(secret);
                 Prk {
+                    algorithm: Algorithm::Sha3_224,
                     inner: PrkInner::RustCrypto(RustCryptoPrk::Sha3_224(
                         salt.finalize().into_bytes(),
                     )),
@@ -189,6 +217,7 @@ impl RustCryptoSalt {
                 let mut salt = salt.clone();
                 salt.update(secret);
                 Prk {
+                    algorithm: Algorithm::Sha3_384,
                     inner: PrkInner::RustCrypto(RustCryptoPrk::Sha3_384(
                         salt.finalize().into_bytes(),
                     )),
@@ -199,6 +228,7 @@ impl RustCryptoSalt {
                 let mut salt = salt.clone();
                 salt.update(secret);
                 Prk {
+                    algorithm: Algorithm::Sha3_512,
                     inner: PrkInner::RustCrypto(RustCryptoPrk::Sha3_512(
                         salt.finalize().into_bytes(),
                     )),
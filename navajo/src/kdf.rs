@@ -0,0 +1,150 @@
+//! Key derivation and extendable output using BLAKE3.
+//!
+//! See [`hkdf`](crate::hkdf) for HMAC-based key derivation.
+
+use crate::error::EmptyContextError;
+
+/// The length of a BLAKE3 key.
+pub const BLAKE3_KEY_LEN: usize = blake3::KEY_LEN;
+
+/// Derives `output.len()` bytes from `key_material` using BLAKE3's
+/// `derive_key` mode.
+///
+/// `context` should be a hardcoded, globally unique and application-specific
+/// string. Per the BLAKE3 specification, it must not be empty.
+///
+/// # Example
+/// ```rust
+/// use navajo::kdf::blake3_derive_key;
+///
+/// let mut key = [0u8; 32];
+/// blake3_derive_key("example.com 2023-03-01 session keys", b"secret", &mut key).unwrap();
+/// ```
+pub fn blake3_derive_key(
+    context: &str,
+    key_material: &[u8],
+    output: &mut [u8],
+) -> Result<(), EmptyContextError> {
+    blake3_derive_key_xof(context, key_material)?.fill(output);
+    Ok(())
+}
+
+/// Returns a [`Blake3Xof`] which produces an unbounded stream of bytes
+/// derived from `key_material` using BLAKE3's `derive_key` mode.
+///
+/// The first 32 bytes are identical to the output of
+/// [`blake3_derive_key`] with the same inputs.
+pub fn blake3_derive_key_xof(
+    context: &str,
+    key_material: &[u8],
+) -> Result<Blake3Xof, EmptyContextError> {
+    if context.is_empty() {
+        return Err(EmptyContextError);
+    }
+    let mut hasher = blake3::Hasher::new_derive_key(context);
+    hasher.update(key_material);
+    Ok(Blake3Xof(hasher.finalize_xof()))
+}
+
+/// Returns a [`Blake3Xof`] which produces an unbounded stream of bytes from
+/// the keyed hash of `data`.
+///
+/// The first 32 bytes are the BLAKE3 keyed hash (MAC) of `data`.
+pub fn blake3_keyed_xof(key: &[u8; BLAKE3_KEY_LEN], data: &[u8]) -> Blake3Xof {
+    let mut hasher = blake3::Hasher::new_keyed(key);
+    hasher.update(data);
+    Blake3Xof(hasher.finalize_xof())
+}
+
+/// An extendable output reader for BLAKE3.
+///
+/// Successive calls to [`fill`](Blake3Xof::fill) continue where the previous
+/// left off.
+#[derive(Clone)]
+pub struct Blake3Xof(blake3::OutputReader);
+
+impl Blake3Xof {
+    /// Fills `buf` with the next `buf.len()` bytes of output.
+    pub fn fill(&mut self, buf: &mut [u8]) {
+        self.0.fill(buf)
+    }
+    /// Returns the current position in the output stream.
+    pub fn position(&self) -> u64 {
+        self.0.position()
+    }
+}
+
+#[cfg(feature = "std")]
+impl std::io::Read for Blake3Xof {
+    fn read(&mut self, buf: &mut [u8]) -> std::io::Result<usize> {
+        self.fill(buf);
+        Ok(buf.len())
+    }
+}
+
+#[cfg(test)]
+mod tests {
+    use super::*;
+    use alloc::vec;
+    use alloc::vec::Vec;
+
+    // Test vectors from the BLAKE3 reference implementation's
+    // test_vectors.json, with an input length of 1025.
+    const KEY: &[u8; 32] = b"whats the Elvish word for friend";
+    const CONTEXT: &str = "BLAKE3 2019-12-27 16:29:52 test vectors context";
+
+    fn input() -> Vec<u8> {
+        (0..1025).map(|i| (i % 251) as u8).collect()
+    }
+
+    #[test]
+    fn test_keyed_hash() {
+        let expected = hex::decode(concat!(
+            "357dc55de0c7e382c900fd6e320acc04146be01db6a8ce7210b7189bd664ea69",
+            "362396b77fdc0d2634a552970843722066c3c15902ae5097e00ff53f1e116f1c",
+            "d5352720113a837ab2452cafbde4d54085d9cf5d21ca613071551b25d52e69d6",
+            "c81123872b6f19cd3bc1333edf0c52b94de23ba772cf82636cff4542540a7738",
+            "d5b930",
+        ))
+        .unwrap();
+        let mut output = vec![0u8; expected.len()];
+        blake3_keyed_xof(KEY, &input()).fill(&mut output);
+        assert_eq!(output, expected);
+
+        let mac = crate::mac::Mac::new_external_key(KEY, crate::mac::Algorithm::Blake3, None, None)
+            .unwrap();
+        let tag = mac.compute(&input()).omit_header().unwrap();
+        assert_eq!(tag.as_bytes(), &expected[..32]);
+    }
+
+    #[test]
+    fn test_derive_key() {
+        let expected = hex::decode(concat!(
+            "effaa245f065fbf82ac186839a249707c3bddf6d3fdda22d1b95a3c970379bcb",
+            "5d31013a167509e9066273ab6e2123bc835b408b067d88f96addb550d96b6852",
+            "dad38e320b9d940f86db74d398c770f462118b35d2724efa13da97194491d96d",
+            "d37c3c09cbef665953f2ee85ec83d88b88d11547a6f911c8217cca46defa2751",
+            "e7f3ad",
+        ))
+        .unwrap();
+        let mut key = [0u8; 32];
+        blake3_derive_key(CONTEXT, &input(), &mut key).unwrap();
+        assert_eq!(key[..], expected[..32]);
+
+        // a long output read in pieces
+        let mut xof = blake3_derive_key_xof(CONTEXT, &input()).unwrap();
+        let mut output = vec![0u8; expected.len()];
+        let (head, tail) = output.split_at_mut(50);
+        xof.fill(head);
+        xof.fill(tail);
+        assert_eq!(output, expected);
+        assert_eq!(xof.position(), expected.len() as u64);
+    }
+
+    #[test]
+    fn test_empty_context() {
+        let mut key = [0u8; 32];
+        assert!(blake3_derive_key("", b"secret", &mut key).is_err());
+        assert!(blake3_derive_key_xof("", b"secret").is_err());
+    }
+}
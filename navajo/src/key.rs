@@ -6,7 +6,11 @@ use serde_json::Value;
 use std::sync::Arc;
 use zeroize::ZeroizeOnDrop;
 
-use crate::{error::DisableKeyError, primitive::Kind, KeyInfo, Origin, Status};
+use crate::{
+    error::{DisableKeyError, KeyError},
+    primitive::Kind,
+    KeyInfo, Origin, Status, Usage,
+};
 
 pub(crate) trait KeyMaterial:
     Send + Sync + ZeroizeOnDrop + Clone + 'static + PartialEq + Eq
@@ -14,6 +18,11 @@ pub(crate) trait KeyMaterial:
     type Algorithm: PartialEq + Eq;
     fn algorithm(&self) -> Self::Algorithm;
     fn kind() -> Kind;
+    /// Checks that the material is usable with its algorithm. Called for
+    /// each key when a keyring is deserialized.
+    fn validate(&self) -> Result<(), KeyError> {
+        Ok(())
+    }
 }
 #[derive(Debug, Clone, Serialize, Deserialize, ZeroizeOnDrop)]
 pub(crate) struct Key<M>
@@ -29,6 +38,9 @@ where
     #[zeroize(skip)]
     #[serde(skip_serializing_if = "Option::is_none")]
     meta: Option<Arc<Value>>,
+    #[zeroize(skip)]
+    #[serde(default, skip_serializing_if = "Usage::is_encrypt_decrypt")]
+    usage: Usage,
 }
 impl<M> Key<M>
 where
@@ -47,6 +59,7 @@ where
             origin,
             material,
             meta: meta.map(Arc::new),
+            usage: Usage::default(),
         }
     }
     pub(crate) fn id(&self) -> u32 {
@@ -96,8 +109,16 @@ where
             status: self.status,
             algorithm: self.material.algorithm(),
             meta: self.meta.clone(),
+            usage: self.usage,
         }
     }
+    pub(crate) fn update_usage(&mut self, usage: Usage) -> &Key<M> {
+        self.usage = usage;
+        self
+    }
+    pub(crate) fn usage(&self) -> Usage {
+        self.usage
+    }
     pub(crate) fn update_meta(&mut self, meta: Option<serde_json::Value>) -> &Key<M> {
         self.meta = meta.map(Arc::new);
         self
@@ -5,16 +5,20 @@ use serde_json::Value;
 #[cfg(feature = "std")]
 use std::sync::Arc;
 
-use crate::{KeyMaterial, Origin, Status};
+use crate::{KeyMaterial, Origin, Status, Usage};
 
 #[derive(Debug, Clone, Serialize, Deserialize)]
 /// Metadata for a particular key.
 pub struct KeyInfo<A> {
+    /// The key's id. Ids generated by navajo are never `0`; keys of an
+    /// imported keyring may have any id.
     pub id: u32,
     pub status: Status,
     pub origin: Origin,
     pub algorithm: A,
     pub meta: Option<Arc<Value>>,
+    #[serde(default)]
+    pub usage: Usage,
 }
 impl<A> PartialEq for KeyInfo<A>
 where
@@ -45,6 +49,7 @@ impl From<crate::aead::AeadKeyInfo> for KeyInfo<crate::aead::Algorithm> {
             origin: info.origin,
             status: info.status,
             meta: info.meta,
+            usage: info.usage,
         }
     }
 }
@@ -57,6 +62,7 @@ impl From<crate::mac::MacKeyInfo> for KeyInfo<crate::mac::Algorithm> {
             origin: info.origin,
             status: info.status,
             meta: info.meta,
+            usage: info.usage,
         }
     }
 }
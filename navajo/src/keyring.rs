@@ -3,10 +3,13 @@ use core::ops::Index;
 
 use crate::envelope::Envelope;
 use crate::error::DisableKeyError;
+use crate::error::IncompatibleKeyringError;
 use crate::error::KeyNotFoundError;
 use crate::error::OpenError;
+use crate::error::PromoteKeyError;
 use crate::error::RemoveKeyError;
 use crate::error::SealError;
+use crate::error::UpdateKeyUsageError;
 use crate::key::Key;
 use crate::key::KeyMaterial;
 use crate::primitive::Kind;
@@ -14,6 +17,7 @@ use crate::rand::Rng;
 use crate::Aad;
 use crate::Origin;
 use crate::Status;
+use crate::Usage;
 
 use aes_gcm::Aes256Gcm;
 use alloc::sync::Arc;
@@ -40,12 +44,31 @@ const PRIMARY_KEY_NOT_FOUND_MSG: &str =
     "primary key not found in keyring\n\t\n\tthis is a bug. please report it to {NEW_ISSUE_URL}";
 
 pub(crate) const KEY_ID_LEN: usize = 4;
+/// Ids generated by navajo are never less than this value, so a generated
+/// key never has the id `0`.
+pub(crate) const MIN_GENERATED_KEY_ID: u32 = 100_000_000;
 
-#[derive(Debug, Clone, Serialize, Deserialize)]
+#[derive(Debug, Clone, Deserialize)]
 struct Keys<M>(Arc<[Key<M>]>)
 where
     M: KeyMaterial;
 
+// Keys are serialized in order of id so that equal keyrings serialize
+// identically regardless of the order keys were added.
+impl<M> Serialize for Keys<M>
+where
+    M: KeyMaterial + Serialize,
+{
+    fn serialize<S>(&self, serializer: S) -> Result<S::Ok, S::Error>
+    where
+        S: serde::Serializer,
+    {
+        let mut keys: Vec<&Key<M>> = self.0.iter().collect();
+        keys.sort_by_key(|k| k.id());
+        serializer.collect_seq(keys)
+    }
+}
+
 impl<M, R> From<R> for Keys<M>
 where
     M: KeyMaterial,
@@ -60,7 +83,10 @@ where
     M: KeyMaterial,
 {
     fn eq(&self, other: &Self) -> bool {
-        self.0.iter().eq(other.0.iter())
+        self.len() == other.len()
+            && self
+                .iter()
+                .all(|key| matches!(other.get(key.id()), Some((_, k)) if k == key))
     }
 }
 
@@ -183,10 +209,21 @@ where
                 "unsupported keyring version: {version}"
             )));
         }
+        for key in &keys {
+            key.material()
+                .validate()
+                .map_err(|e| serde::de::Error::custom(format!("navajo: key {}: {e}", key.id())))?;
+        }
         let mut primary_key_idx = None;
         for idx in 0..keys.len() {
             let key = &keys[idx];
             if key.status().is_primary() {
+                if key.usage() == Usage::DecryptOnly {
+                    return Err(serde::de::Error::custom(format!(
+                        "navajo: primary key {} is decrypt-only",
+                        key.id()
+                    )));
+                }
                 if let Some(former_primary) = primary_key_idx {
                     let k: &mut Key<M> = keys.get_mut(former_primary).unwrap();
                     k.demote();
@@ -309,6 +346,21 @@ where
         Ok(self.keys.update(key).unwrap())
     }
 
+    pub(crate) fn update_usage(
+        &mut self,
+        id: impl Into<u32>,
+        usage: Usage,
+    ) -> Result<&Key<M>, UpdateKeyUsageError<M::Algorithm>> {
+        let id = id.into();
+        let primary = self.primary();
+        if id == primary.id() && usage.is_decrypt_only() {
+            return Err(UpdateKeyUsageError::IsPrimaryKey(primary.info()));
+        }
+        let mut key = self.get(id)?.clone();
+        key.update_usage(usage);
+        Ok(self.keys.update(key).unwrap())
+    }
+
     pub(crate) fn enable(&mut self, id: impl Into<u32>) -> Result<&Key<M>, KeyNotFoundError> {
         let id = id.into();
         let mut key = self.get(id)?.clone();
@@ -317,13 +369,19 @@ where
     }
 
     // Returns the previous primary key
-    pub(crate) fn promote(&mut self, id: impl Into<u32>) -> Result<&Key<M>, KeyNotFoundError> {
+    pub(crate) fn promote(
+        &mut self,
+        id: impl Into<u32>,
+    ) -> Result<&Key<M>, PromoteKeyError<M::Algorithm>> {
         let id = id.into();
         let (idx, mut key) = self
             .keys
             .get(id)
             .map(|(idx, key)| (idx, key.clone()))
             .ok_or(KeyNotFoundError(id))?;
+        if key.usage().is_decrypt_only() {
+            return Err(PromoteKeyError::DecryptOnly(key.info()));
+        }
         let prev_primary = self.primary_key_idx;
         if key.status() == Status::Primary {
             return Ok(self.keys.get_by_idx(prev_primary).unwrap());
@@ -342,6 +400,19 @@ where
         &self.keys
     }
 
+    /// Checks that every key of `other` which shares an id with a key in
+    /// this keyring has the same material and origin.
+    pub(crate) fn compatible_with(&self, other: &Self) -> Result<(), IncompatibleKeyringError> {
+        for key in other.keys.iter() {
+            if let Ok(existing) = self.get(key.id()) {
+                if existing.material() != key.material() || existing.origin() != key.origin() {
+                    return Err(IncompatibleKeyringError::ConflictingKey(key.id()));
+                }
+            }
+        }
+        Ok(())
+    }
+
     fn gen_unique_id<G>(&self, rng: &G) -> u32
     where
         G: Rng,
@@ -363,24 +434,12 @@ where
         let material = serde_json::to_value(&self.keys).unwrap();
         let kind = serde_json::to_value(M::kind()).unwrap();
         let version = serde_json::to_value(self.version).unwrap();
-        let result: Vec<u8>;
-        #[cfg(feature = "std")]
-        {
-            let mut data = std::collections::HashMap::with_capacity(3);
-            data.insert("k", kind);
-            data.insert("v", version);
-            data.insert("m", material);
-            result = serde_json::to_vec(&data)?;
-        }
-        #[cfg(not(feature = "std"))]
-        {
-            let mut data = alloc::collections::BTreeMap::new();
-            data.insert("k", kind);
-            data.insert("v", version);
-            data.insert("m", material);
-            result = serde_json::to_vec(&data)?;
-        }
-        Ok(result)
+        // a BTreeMap, rather than a HashMap, keeps the field order stable.
+        let mut data = alloc::collections::BTreeMap::new();
+        data.insert("k", kind);
+        data.insert("v", version);
+        data.insert("m", material);
+        Ok(serde_json::to_vec(&data)?)
     }
 
     fn seal_first_pass(&self, aad: &[u8]) -> Result<Vec<u8>, SealError> {
@@ -534,9 +593,13 @@ fn get_kind(value: &Value) -> Result<Kind, OpenError> {
 
 impl<M> Keyring<M> where M: KeyMaterial + DeserializeOwned {}
 
+/// Generates a key id of at least [`MIN_GENERATED_KEY_ID`].
+///
+/// Keyrings which are deserialized are not held to this; a key created
+/// elsewhere may have any id, including `0`, and is used as-is.
 pub(crate) fn gen_id<G: Rng>(rng: &G) -> u32 {
     let mut value = rng.u32().unwrap();
-    while value < 100_000_000 {
+    while value < MIN_GENERATED_KEY_ID {
         value = rng.u32().unwrap();
     }
     value
@@ -625,6 +688,30 @@ mod tests {
         assert_eq!(keyring, de);
     }
 
+    #[cfg(feature = "std")]
+    #[test]
+    fn test_gen_id_skips_low_ids() {
+        let rng = crate::rand::MockRandom::new();
+        let mut values = vec![0, MIN_GENERATED_KEY_ID - 1, MIN_GENERATED_KEY_ID].into_iter();
+        rng.lock()
+            .expect_u32()
+            .times(3)
+            .returning(move || Ok(values.next().unwrap()));
+        assert_eq!(gen_id(&rng), MIN_GENERATED_KEY_ID);
+    }
+
+    #[test]
+    fn test_deserialize_key_id_zero() {
+        let material = Material::new(Algorithm::Waffles);
+        let keyring = Keyring::new(&SystemRng, material, Origin::Navajo, None);
+        assert!(keyring.primary().id() >= MIN_GENERATED_KEY_ID);
+        let mut value = serde_json::to_value(&keyring).unwrap();
+        value["keys"][0]["id"] = serde_json::json!(0u32);
+        let keyring: Keyring<Material> = serde_json::from_value(value).unwrap();
+        assert_eq!(keyring.primary().id(), 0);
+        assert!(keyring.get(0).is_ok());
+    }
+
     #[cfg(feature = "std")]
     #[tokio::test]
     async fn test_seal_and_open() {
@@ -655,6 +742,44 @@ mod tests {
         assert_eq!(keyring, opened);
     }
 
+    #[test]
+    fn test_serialization_is_deterministic() {
+        let material = Material::new(Algorithm::Waffles);
+        let mut keyring = Keyring::new(&SystemRng, material, Origin::Navajo, Some("test".into()));
+        keyring.add(
+            &SystemRng,
+            Material::new(Algorithm::Cereal),
+            Origin::Navajo,
+            Some(serde_json::json!({ "b": 1, "a": [1, 2] })),
+        );
+        keyring.add(
+            &SystemRng,
+            Material::new(Algorithm::Pancakes),
+            Origin::Navajo,
+            None,
+        );
+        let reversed: Vec<Key<Material>> = keyring.keys().iter().rev().cloned().collect();
+        let primary_key_idx = reversed.iter().position(|k| k.is_primary()).unwrap();
+        let reversed = Keyring {
+            version: 0,
+            keys: Keys::from(reversed),
+            primary_key_idx,
+        };
+        assert_eq!(keyring, reversed);
+        assert_eq!(
+            serde_json::to_vec(&keyring).unwrap(),
+            serde_json::to_vec(&reversed).unwrap()
+        );
+        assert_eq!(
+            keyring.serialize_for_sealing().unwrap(),
+            reversed.serialize_for_sealing().unwrap()
+        );
+        assert_eq!(
+            serde_json::to_vec(&keyring).unwrap(),
+            serde_json::to_vec(&keyring).unwrap()
+        );
+    }
+
     #[test]
     fn test_key_status() {
         let material = Material::new(Algorithm::Pancakes);
@@ -29,6 +29,9 @@ pub mod error;
 #[cfg(feature = "hkdf")]
 pub mod hkdf;
 
+#[cfg(feature = "blake3")]
+pub mod kdf;
+
 mod id;
 #[cfg(any(
     feature = "aead",
@@ -93,11 +96,17 @@ pub mod primitive;
 pub mod rand;
 pub use rand::{Rng, SystemRng};
 
+mod self_test;
+pub use self_test::self_test;
+
 pub mod sensitive;
 
 mod status;
 pub use status::Status;
 
+mod usage;
+pub use usage::Usage;
+
 #[cfg(feature = "signature")]
 pub mod signature;
 #[cfg(feature = "signature")]
@@ -24,10 +24,11 @@ pub use verifier::Verifier;
 use zeroize::ZeroizeOnDrop;
 
 use crate::error::{
-    KeyError, KeyNotFoundError, MacVerificationError, OpenError, RemoveKeyError, SealError,
+    IncompatibleKeyringError, KeyError, KeyNotFoundError, MacVerificationError, OpenError,
+    RemoveKeyError, SealError,
 };
 use crate::primitive::Primitive;
-use crate::rand::{Rng, SystemRng};
+use crate::rand::{is_weak, Rng, SystemRng};
 use crate::{Aad, Envelope, Keyring, Origin};
 use alloc::vec::Vec;
 use context::*;
@@ -230,6 +231,25 @@ impl Mac {
     {
         Self::import_key(SystemRng, key, algorithm, prefix, meta)
     }
+
+    /// Same as [`new_external_key`](Mac::new_external_key) but rejects weak
+    /// keys, i.e. keys which are all zero or a single repeated byte.
+    ///
+    /// # Errors
+    /// Errors if the key is weak or the key length is invalid for the
+    /// [`Algorithm`].
+    pub fn new_external_key_strict<K>(
+        key: K,
+        algorithm: Algorithm,
+        prefix: Option<&[u8]>,
+        meta: Option<serde_json::Value>,
+    ) -> Result<Self, KeyError>
+    where
+        K: AsRef<[u8]>,
+    {
+        check_key_strength(key.as_ref())?;
+        Self::import_key(SystemRng, key, algorithm, prefix, meta)
+    }
     #[cfg(test)]
     pub fn new_external_key_with_rng<R, K>(
         rand: R,
@@ -374,6 +394,51 @@ impl Mac {
         verify.finalize()
     }
 
+    /// Computes a [`Tag`] over multiple fields.
+    ///
+    /// Each field is prefixed with its length as a big-endian `u64` so that
+    /// different groupings of the same bytes (e.g. `["ab", "c"]` and
+    /// `["a", "bc"]`) produce different tags.
+    ///
+    /// # Example
+    /// ```rust
+    /// use navajo::mac::{Mac, Algorithm};
+    ///
+    /// let mac = Mac::new(Algorithm::Sha256, None);
+    /// let tag = mac.compute_fields([&b"ab"[..], b"c"]);
+    /// assert!(mac.verify_fields(&tag, [&b"ab"[..], b"c"]).is_ok());
+    /// assert!(mac.verify_fields(&tag, [&b"a"[..], b"bc"]).is_err());
+    /// ```
+    pub fn compute_fields<I, F>(&self, fields: I) -> Tag
+    where
+        I: IntoIterator<Item = F>,
+        F: AsRef<[u8]>,
+    {
+        let mut compute = Computer::new(self);
+        for field in fields {
+            let field = field.as_ref();
+            compute.update(&(field.len() as u64).to_be_bytes());
+            compute.update(field);
+        }
+        compute.finalize()
+    }
+
+    /// Verifies a [`Tag`] computed by [`compute_fields`](Mac::compute_fields).
+    pub fn verify_fields<T, I, F>(&self, tag: T, fields: I) -> Result<Tag, MacVerificationError>
+    where
+        T: AsRef<Tag>,
+        I: IntoIterator<Item = F>,
+        F: AsRef<[u8]>,
+    {
+        let mut verify = Verifier::new(tag, self);
+        for field in fields {
+            let field = field.as_ref();
+            verify.update(&(field.len() as u64).to_be_bytes());
+            verify.update(field);
+        }
+        verify.finalize()
+    }
+
     /// Verifies a [`Tag`] for the given [`Read`] `reader` using the primary key.
     /// # Example
     /// ```rust
@@ -534,10 +599,38 @@ impl Mac {
         self.create_key(rng, algorithm, key.as_ref(), prefix, Origin::External, meta)
     }
 
+    /// Same as [`add_external_key`](Mac::add_external_key) but rejects weak
+    /// keys, i.e. keys which are all zero or a single repeated byte.
+    ///
+    /// # Errors
+    /// Errors if the key is weak or the key length is invalid for the
+    /// [`Algorithm`].
+    pub fn add_external_key_strict<K>(
+        &mut self,
+        key: K,
+        algorithm: Algorithm,
+        prefix: Option<&[u8]>,
+        meta: Option<serde_json::Value>,
+    ) -> Result<MacKeyInfo, KeyError>
+    where
+        K: AsRef<[u8]>,
+    {
+        check_key_strength(key.as_ref())?;
+        self.add_external_key(key, algorithm, prefix, meta)
+    }
+
     /// Returns [`MacKeyInfo`] for the primary key.
     pub fn primary_key(&self) -> MacKeyInfo {
         self.keyring.primary().into()
     }
+
+    /// Returns the id of the primary key.
+    ///
+    /// This is equivalent to `primary_key().id` without constructing the
+    /// [`MacKeyInfo`].
+    pub fn primary_key_id(&self) -> u32 {
+        self.keyring.primary().id()
+    }
     /// Returns a [`Vec`] containing a [`MacKeyInfo`] for each key in this keyring.
     pub fn keys(&self) -> Vec<MacKeyInfo> {
         self.keyring.keys().iter().map(MacKeyInfo::new).collect()
@@ -546,10 +639,23 @@ impl Mac {
     pub fn promote_key(
         &mut self,
         key_id: impl Into<u32>,
-    ) -> Result<MacKeyInfo, crate::error::KeyNotFoundError> {
+    ) -> Result<MacKeyInfo, crate::error::PromoteKeyError<Algorithm>> {
         self.keyring.promote(key_id).map(MacKeyInfo::new)
     }
 
+    /// Sets the [`Usage`](crate::Usage) of a key. A
+    /// [`DecryptOnly`](crate::Usage::DecryptOnly) key can not be promoted to
+    /// primary.
+    pub fn update_key_usage(
+        &mut self,
+        key_id: impl Into<u32>,
+        usage: crate::Usage,
+    ) -> Result<MacKeyInfo, crate::error::UpdateKeyUsageError<Algorithm>> {
+        self.keyring
+            .update_usage(key_id, usage)
+            .map(MacKeyInfo::new)
+    }
+
     pub fn disable_key(
         &mut self,
         key_id: impl Into<u32>,
@@ -568,6 +674,10 @@ impl Mac {
         self.keyring.remove(key_id).map(|k| MacKeyInfo::new(&k))
     }
 
+    /// Replaces the metadata of the key with `key_id`.
+    ///
+    /// Metadata, such as a map of labels, is serialized with the keyring but
+    /// is not bound to computed tags.
     pub fn update_key_meta(
         &mut self,
         key_id: impl Into<u32>,
@@ -576,6 +686,16 @@ impl Mac {
         self.keyring.update_meta(key_id, meta).map(MacKeyInfo::new)
     }
 
+    /// Checks whether the keys of `other` can be combined with this
+    /// keyring, i.e. no key id is shared by keys with different material.
+    ///
+    /// # Errors
+    /// Returns [`IncompatibleKeyringError::ConflictingKey`] for the first
+    /// conflicting key.
+    pub fn compatible_with(&self, other: &Mac) -> Result<(), IncompatibleKeyringError> {
+        self.keyring.compatible_with(&other.keyring)
+    }
+
     pub(crate) fn keyring(&self) -> &Keyring<Material> {
         &self.keyring
     }
@@ -607,3 +727,99 @@ impl AsRef<Mac> for Mac {
         self
     }
 }
+
+fn check_key_strength(key: &[u8]) -> Result<(), KeyError> {
+    if is_weak(key) {
+        return Err("weak key; all bytes are zero or the same value".into());
+    }
+    Ok(())
+}
+
+#[cfg(test)]
+mod tests {
+    use super::*;
+
+    #[test]
+    fn test_strict_rejects_weak_keys() {
+        let zero = [0u8; 32];
+        let repeating = [7u8; 32];
+        for key in [&zero, &repeating] {
+            assert!(Mac::new_external_key_strict(key, Algorithm::Sha256, None, None).is_err());
+            assert!(Mac::new_external_key(key, Algorithm::Sha256, None, None).is_ok());
+        }
+        let mut mac = Mac::new(Algorithm::Sha256, None);
+        assert!(mac
+            .add_external_key_strict(zero, Algorithm::Sha256, None, None)
+            .is_err());
+        assert!(mac
+            .add_external_key(zero, Algorithm::Sha256, None, None)
+            .is_ok());
+
+        let mut key = [0u8; 32];
+        SystemRng.fill(&mut key).unwrap();
+        assert!(Mac::new_external_key_strict(key, Algorithm::Sha256, None, None).is_ok());
+    }
+    #[test]
+    fn test_empty_data() {
+        use strum::IntoEnumIterator;
+        for algorithm in Algorithm::iter() {
+            let mac = Mac::new(algorithm, None);
+            let tag = mac.compute(b"");
+            assert!(mac.verify(&tag, b"").is_ok());
+            assert!(mac.verify(&tag, b"x").is_err());
+        }
+    }
+
+    #[test]
+    fn test_rotated_keyring_verifies_old_and_new_tags() {
+        let mut mac = Mac::new(Algorithm::Sha256, None);
+        let old_key = mac.primary_key();
+        let old_tag = mac.compute(b"hello world").as_bytes().to_vec();
+        assert_eq!(&old_tag[..4], &old_key.id.to_be_bytes()[..]);
+
+        let new_key = mac.add_key(Algorithm::Sha512, None);
+        mac.promote_key(new_key.id).unwrap();
+        let new_tag = mac.compute(b"hello world").as_bytes().to_vec();
+        assert_eq!(&new_tag[..4], &new_key.id.to_be_bytes()[..]);
+
+        let computed = mac.compute(b"hello world");
+        assert_eq!(computed, old_tag);
+        assert_eq!(computed, new_tag);
+        assert_eq!(computed, old_tag[4..].to_vec());
+        assert_ne!(mac.compute(b"goodbye"), old_tag);
+    }
+
+    #[test]
+    fn test_sha512_224() {
+        let key = hex::decode("85bcda2d6d76b547e47d8e6ca49b95ff19ea5d8b4e37569b72367d5aa0336d22")
+            .unwrap();
+        let mac = Mac::new_external_key(&key, Algorithm::Sha512_224, None, None).unwrap();
+        let tag = mac.compute(b"hello world").omit_header().unwrap();
+        assert_eq!(
+            hex::encode(&tag),
+            "ce1162e62dabadc28d1831ec223cf66030dc26702750ebfb3cf88ce0"
+        );
+        // SHA-512/224 has distinct initial hash values; it is not a truncation of SHA-512
+        let mac = Mac::new_external_key(&key, Algorithm::Sha512, None, None).unwrap();
+        let tag = mac.compute(b"hello world").omit_header().unwrap();
+        assert_ne!(
+            &hex::encode(&tag)[..56],
+            "ce1162e62dabadc28d1831ec223cf66030dc26702750ebfb3cf88ce0"
+        );
+    }
+
+    #[test]
+    fn test_compute_fields() {
+        use strum::IntoEnumIterator;
+        for algorithm in Algorithm::iter() {
+            let mac = Mac::new(algorithm, None);
+            let ab_c = mac.compute_fields([&b"ab"[..], b"c"]);
+            let a_bc = mac.compute_fields([&b"a"[..], b"bc"]);
+            assert_ne!(ab_c, a_bc);
+            assert_ne!(mac.compute_fields([&b"abc"[..]]), mac.compute(b"abc"));
+            assert!(mac.verify_fields(&ab_c, [&b"ab"[..], b"c"]).is_ok());
+            assert!(mac.verify_fields(&ab_c, [&b"a"[..], b"bc"]).is_err());
+            assert!(mac.verify_fields(&a_bc, [&b"a"[..], b"bc"]).is_ok());
+        }
+    }
+}
@@ -54,6 +54,13 @@ pub enum Algorithm {
     #[strum(serialize = "SHA2-512")]
     Sha512,
 
+    /// HMAC with SHA-512/224 as defined in FIPS 180-4, which uses its own
+    /// initial hash value rather than truncating SHA-512.
+    #[cfg(all(feature = "sha2", feature = "hmac"))]
+    #[serde(rename = "SHA2-512-224")]
+    #[strum(serialize = "SHA2-512-224")]
+    Sha512_224,
+
     #[cfg(all(feature = "sha3", feature = "hmac"))]
     #[serde(rename = "SHA3-256")]
     #[strum(serialize = "SHA3-256")]
@@ -108,6 +115,8 @@ impl Algorithm {
             Algorithm::Sha384 => 48,
             #[cfg(any(feature = "ring", all(feature = "sha2", feature = "hmac")))]
             Algorithm::Sha512 => 64,
+            #[cfg(all(feature = "sha2", feature = "hmac"))]
+            Algorithm::Sha512_224 => 28,
             #[cfg(all(feature = "sha3", feature = "hmac"))]
             Algorithm::Sha3_256 => 32,
             #[cfg(all(feature = "sha3", feature = "hmac"))]
@@ -172,6 +181,8 @@ impl Algorithm {
             Algorithm::Sha384 => SHA2_384_KEY_LEN,
             #[cfg(any(feature = "ring", all(feature = "sha2", feature = "hmac")))]
             Algorithm::Sha512 => SHA2_512_KEY_LEN,
+            #[cfg(all(feature = "sha2", feature = "hmac"))]
+            Algorithm::Sha512_224 => SHA2_512_224_KEY_LEN,
             #[cfg(all(feature = "sha3", feature = "hmac"))]
             Algorithm::Sha3_224 => SHA3_224_KEY_LEN,
             #[cfg(all(feature = "sha3", feature = "hmac"))]
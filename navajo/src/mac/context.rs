@@ -151,6 +151,8 @@ impl RustCryptoContext {
             RustCryptoKey::Sha384(ref mut k) => hmac::Mac::update(k, data),
             #[cfg(all(not(feature = "ring"), feature = "sha2", feature = "hmac"))]
             RustCryptoKey::Sha512(ref mut k) => hmac::Mac::update(k, data),
+            #[cfg(all(feature = "sha2", feature = "hmac"))]
+            RustCryptoKey::Sha512_224(ref mut k) => hmac::Mac::update(k, data),
             #[cfg(all(feature = "sha3", feature = "hmac"))]
             RustCryptoKey::Sha3_256(ref mut k) => hmac::Mac::update(k, data),
             #[cfg(all(feature = "sha3", feature = "hmac"))]
@@ -181,6 +183,10 @@ impl RustCryptoContext {
             RustCryptoKey::Sha512(k) => {
                 RustCryptoOutput::Sha512(hmac::Mac::finalize(k).into_bytes())
             }
+            #[cfg(all(feature = "sha2", feature = "hmac"))]
+            RustCryptoKey::Sha512_224(k) => {
+                RustCryptoOutput::Sha512_224(hmac::Mac::finalize(k).into_bytes())
+            }
             #[cfg(all(feature = "sha3", feature = "hmac"))]
             RustCryptoKey::Sha3_256(k) => {
                 RustCryptoOutput::Sha3_256(hmac::Mac::finalize(k).into_bytes())
@@ -1,7 +1,7 @@
 use alloc::{sync::Arc, vec::Vec};
 use serde::{Deserialize, Serialize};
 
-use crate::{key::Key, Status};
+use crate::{key::Key, Status, Usage};
 
 use super::Algorithm;
 #[derive(Debug, Clone, Serialize, Deserialize, Eq)]
@@ -19,6 +19,8 @@ pub struct MacKeyInfo {
     /// - For external keys, this will be the prefix if supplied.
     /// - For keys generated by Navajo, this will be a version byte and the key ID.
     pub header: Vec<u8>,
+    #[serde(default)]
+    pub usage: Usage,
 }
 
 impl PartialEq for MacKeyInfo {
@@ -37,6 +39,7 @@ impl MacKeyInfo {
             external_prefix: key.material().prefix().map(|p| p.to_vec()),
             header: key.header().to_vec(),
             meta: key.meta(),
+            usage: key.usage(),
         }
     }
 }
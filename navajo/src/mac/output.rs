@@ -110,6 +110,9 @@ pub(super) enum RustCryptoOutput {
     #[cfg(all(not(feature = "ring"), feature = "sha2", feature = "hmac"))]
     Sha512(sha2::digest::Output<sha2::Sha512>),
 
+    #[cfg(all(feature = "sha2", feature = "hmac"))]
+    Sha512_224(sha2::digest::Output<sha2::Sha512_224>),
+
     #[cfg(all(feature = "sha3", feature = "hmac"))]
     Sha3_256(sha3::digest::Output<sha3::Sha3_256>),
 
@@ -146,6 +149,8 @@ impl AsRef<[u8]> for RustCryptoOutput {
             Self::Sha384(output) => output.as_ref(),
             #[cfg(all(not(feature = "ring"), feature = "sha2", feature = "hmac"))]
             Self::Sha512(output) => output.as_ref(),
+            #[cfg(all(feature = "sha2", feature = "hmac"))]
+            Self::Sha512_224(output) => output.as_ref(),
             #[cfg(all(feature = "sha3", feature = "hmac"))]
             Self::Sha3_256(output) => output.as_ref(),
             #[cfg(all(feature = "sha3", feature = "hmac"))]
@@ -173,6 +178,8 @@ impl Deref for RustCryptoOutput {
             Self::Sha384(output) => output.as_ref(),
             #[cfg(all(not(feature = "ring"), feature = "sha2", feature = "hmac"))]
             Self::Sha512(output) => output.as_ref(),
+            #[cfg(all(feature = "sha2", feature = "hmac"))]
+            Self::Sha512_224(output) => output.as_ref(),
             #[cfg(all(feature = "sha3", feature = "hmac"))]
             Self::Sha3_256(output) => output.as_ref(),
             #[cfg(all(feature = "sha3", feature = "hmac"))]
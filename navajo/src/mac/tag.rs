@@ -206,7 +206,15 @@ impl Tag {
         {
             return Ok(());
         }
-        for entry in self.entries.iter() {
+        // Entries whose header prefixes `other` are tried first. The header is
+        // not secret so the prefix match need not be constant time. Entries
+        // without a matching header (e.g. the header was omitted) are tried
+        // afterward.
+        let (matched, rest): (Vec<&Entry>, Vec<&Entry>) = self
+            .entries
+            .iter()
+            .partition(|entry| !entry.header().is_empty() && other.starts_with(entry.header()));
+        for entry in matched.into_iter().chain(rest) {
             if entry.verify(other, self.truncate_to).is_ok() {
                 return Ok(());
             }
@@ -13,7 +13,7 @@ use serde_json::Value;
 
 use crate::{
     envelope::is_cleartext,
-    error::{OpenError, SealError},
+    error::{IncompatibleKeyringError, OpenError, SealError},
     keyring::{open_keyring_value, open_keyring_value_sync, Keyring},
     Aad,
     Envelope,
@@ -246,6 +246,32 @@ impl Primitive {
             Primitive::Signature(_) => Kind::Signature,
         }
     }
+    /// Checks whether `other` is a keyring of the same [`Kind`] whose keys
+    /// can be combined with this keyring's.
+    ///
+    /// # Errors
+    /// Returns [`IncompatibleKeyringError::KindMismatch`] if the primitives
+    /// differ or [`IncompatibleKeyringError::ConflictingKey`] if a key id is
+    /// shared by keys with different material.
+    pub fn compatible_with(&self, other: &Primitive) -> Result<(), IncompatibleKeyringError> {
+        match (self, other) {
+            #[cfg(feature = "aead")]
+            (Primitive::Aead(a), Primitive::Aead(b)) => a.keyring().compatible_with(b.keyring()),
+            #[cfg(feature = "daead")]
+            (Primitive::Daead(a), Primitive::Daead(b)) => a.keyring().compatible_with(b.keyring()),
+            #[cfg(feature = "mac")]
+            (Primitive::Mac(a), Primitive::Mac(b)) => a.keyring().compatible_with(b.keyring()),
+            #[cfg(feature = "signature")]
+            (Primitive::Signature(a), Primitive::Signature(b)) => {
+                a.keyring().compatible_with(b.keyring())
+            }
+            #[allow(unreachable_patterns)]
+            _ => Err(IncompatibleKeyringError::KindMismatch {
+                expected: self.kind(),
+                found: other.kind(),
+            }),
+        }
+    }
     #[cfg(feature = "aead")]
     pub fn aead(self) -> Option<crate::Aead> {
         match self {
@@ -374,4 +400,19 @@ mod tests {
         let mac = crate::Mac::open(Aad::empty(), data, &in_mem).await.unwrap();
         assert_eq!(mac.primary_key(), primary_key);
     }
+
+    #[cfg(all(feature = "aead", feature = "mac"))]
+    #[test]
+    fn test_compatible_with_kind_mismatch() {
+        let aead = Primitive::Aead(crate::Aead::new(crate::aead::Algorithm::Aes256Gcm, None));
+        let mac = Primitive::Mac(crate::Mac::new(crate::mac::Algorithm::Sha256, None));
+        assert!(aead.compatible_with(&aead).is_ok());
+        assert_eq!(
+            aead.compatible_with(&mac),
+            Err(IncompatibleKeyringError::KindMismatch {
+                expected: Kind::Aead,
+                found: Kind::Mac,
+            })
+        );
+    }
 }
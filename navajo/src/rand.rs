@@ -28,13 +28,18 @@ pub(crate) fn is_fully_repeating(key: &[u8]) -> bool {
     if key.len() < 2 {
         return false;
     }
-    !key.iter().cloned().all(|b| b == key[0])
+    key.iter().cloned().all(|b| b == key[0])
 }
 
 pub(crate) fn is_zero(key: &[u8]) -> bool {
     key.iter().cloned().all(|b| b == 0)
 }
 
+/// Reports whether or not a key is all zero or a single repeated byte
+pub(crate) fn is_weak(key: &[u8]) -> bool {
+    is_zero(key) || is_fully_repeating(key)
+}
+
 fn fill(dst: &mut [u8]) -> Result<(), RandomError> {
     #[cfg(feature = "ring")]
     {
@@ -198,6 +203,31 @@ impl Default for MockRandom {
     }
 }
 
+#[cfg(all(test, feature = "std"))]
+impl Rng for MockRandom {
+    fn fill(&self, dst: &mut [u8]) -> Result<(), RandomError> {
+        self.lock().fill(dst)
+    }
+    fn u8(&self) -> Result<u8, RandomError> {
+        self.lock().u8()
+    }
+    fn u16(&self) -> Result<u16, RandomError> {
+        self.lock().u16()
+    }
+    fn u32(&self) -> Result<u32, RandomError> {
+        self.lock().u32()
+    }
+    fn u64(&self) -> Result<u64, RandomError> {
+        self.lock().u64()
+    }
+    fn u128(&self) -> Result<u128, RandomError> {
+        self.lock().u128()
+    }
+    fn usize(&self) -> Result<usize, RandomError> {
+        self.lock().usize()
+    }
+}
+
 #[cfg(all(test, feature = "std"))]
 impl RngCore for MockRandom {
     fn next_u32(&mut self) -> u32 {
@@ -0,0 +1,446 @@
+//! Known-answer tests run by [`self_test`].
+
+#[cfg(any(feature = "aead", feature = "mac", feature = "hkdf"))]
+use alloc::{format, vec::Vec};
+
+use crate::error::SelfTestError;
+
+/// Runs a known-answer test for each AEAD, MAC and HKDF algorithm compiled
+/// into navajo, returning an error naming the first algorithm whose output
+/// does not match.
+///
+/// This is intended to be called once at startup to detect a broken build or
+/// platform before any keys are used.
+///
+/// AEAD algorithms are checked in both directions: the known plaintext must
+/// encrypt to the known ciphertext and decrypt back to the plaintext.
+/// Signature algorithms are not yet covered.
+///
+/// # Example
+/// ```rust
+/// navajo::self_test().expect("navajo self-test failed");
+/// ```
+pub fn self_test() -> Result<(), SelfTestError> {
+    #[cfg(any(feature = "aead", feature = "mac", feature = "hkdf"))]
+    return run(&VECTORS);
+    #[cfg(not(any(feature = "aead", feature = "mac", feature = "hkdf")))]
+    Ok(())
+}
+
+#[cfg(any(feature = "aead", feature = "mac", feature = "hkdf"))]
+#[derive(Clone, Copy)]
+struct Vectors<'a> {
+    #[cfg(feature = "aead")]
+    aead: &'a [AeadVector],
+    #[cfg(feature = "mac")]
+    mac: &'a [MacVector],
+    #[cfg(feature = "hkdf")]
+    hkdf: &'a [HkdfVector],
+}
+
+#[cfg(any(feature = "aead", feature = "mac", feature = "hkdf"))]
+fn run(vectors: &Vectors<'_>) -> Result<(), SelfTestError> {
+    #[cfg(feature = "aead")]
+    for v in vectors.aead {
+        v.check()?;
+    }
+    #[cfg(feature = "mac")]
+    for v in vectors.mac {
+        v.check()?;
+    }
+    #[cfg(feature = "hkdf")]
+    for v in vectors.hkdf {
+        v.check()?;
+    }
+    Ok(())
+}
+
+#[cfg(any(feature = "aead", feature = "mac", feature = "hkdf"))]
+const VECTORS: Vectors<'static> = Vectors {
+    #[cfg(feature = "aead")]
+    aead: AEAD_VECTORS,
+    #[cfg(feature = "mac")]
+    mac: MAC_VECTORS,
+    #[cfg(feature = "hkdf")]
+    hkdf: HKDF_VECTORS,
+};
+
+#[cfg(any(feature = "aead", feature = "mac", feature = "hkdf"))]
+fn decode(hex: &str) -> Option<Vec<u8>> {
+    if hex.len() % 2 != 0 {
+        return None;
+    }
+    (0..hex.len())
+        .step_by(2)
+        .map(|i| u8::from_str_radix(hex.get(i..i + 2)?, 16).ok())
+        .collect()
+}
+
+#[cfg(feature = "aead")]
+#[derive(Clone, Copy)]
+struct AeadVector {
+    algorithm: crate::aead::Algorithm,
+    key: &'static str,
+    nonce: &'static str,
+    aad: &'static str,
+    plaintext: &'static str,
+    ciphertext: &'static str,
+}
+
+#[cfg(feature = "aead")]
+impl AeadVector {
+    fn matches(&self) -> Option<bool> {
+        Some(crate::aead::self_test::check(
+            self.algorithm,
+            &decode(self.key)?,
+            &decode(self.nonce)?,
+            &decode(self.aad)?,
+            &decode(self.plaintext)?,
+            &decode(self.ciphertext)?,
+        ))
+    }
+    fn check(&self) -> Result<(), SelfTestError> {
+        if self.matches() == Some(true) {
+            Ok(())
+        } else {
+            Err(SelfTestError {
+                algorithm: format!("{}", self.algorithm),
+            })
+        }
+    }
+}
+
+#[cfg(feature = "aead")]
+const AEAD_VECTORS: &[AeadVector] = &[
+    // The Galois/Counter Mode of Operation (GCM), test case 2
+    AeadVector {
+        algorithm: crate::aead::Algorithm::Aes128Gcm,
+        key: "00000000000000000000000000000000",
+        nonce: "000000000000000000000000",
+        aad: "",
+        plaintext: "00000000000000000000000000000000",
+        ciphertext: "0388dace60b6a392f328c2b971b2fe78ab6e47d42cec13bdf53a67b21257bddf",
+    },
+    // The Galois/Counter Mode of Operation (GCM), test case 14
+    AeadVector {
+        algorithm: crate::aead::Algorithm::Aes256Gcm,
+        key: "0000000000000000000000000000000000000000000000000000000000000000",
+        nonce: "000000000000000000000000",
+        aad: "",
+        plaintext: "00000000000000000000000000000000",
+        ciphertext: "cea7403d4d606b6e074ec5d3baf39d18d0d1c8a799996bf0265b98b5d48ab919",
+    },
+    // RFC 8439, section 2.8.2
+    AeadVector {
+        algorithm: crate::aead::Algorithm::ChaCha20Poly1305,
+        key: "808182838485868788898a8b8c8d8e8f909192939495969798999a9b9c9d9e9f",
+        nonce: "070000004041424344454647",
+        aad: "50515253c0c1c2c3c4c5c6c7",
+        plaintext: SUNSCREEN,
+        ciphertext: concat!(
+            "d31a8d34648e60db7b86afbc53ef7ec2a4aded51296e08fea9e2b5a736ee62d6",
+            "3dbea45e8ca9671282fafb69da92728b1a71de0a9e060b2905d6a5b67ecd3b36",
+            "92ddbd7f2d778b8c9803aee328091b58fab324e4fad675945585808b4831d7bc",
+            "3ff4def08e4b7a9de576d26586cec64b6116",
+            "1ae10b594f09e26a7e902ecbd0600691",
+        ),
+    },
+    // draft-irtf-cfrg-xchacha-03, appendix A.3.1
+    AeadVector {
+        algorithm: crate::aead::Algorithm::XChaCha20Poly1305,
+        key: "808182838485868788898a8b8c8d8e8f909192939495969798999a9b9c9d9e9f",
+        nonce: "404142434445464748494a4b4c4d4e4f5051525354555657",
+        aad: "50515253c0c1c2c3c4c5c6c7",
+        plaintext: SUNSCREEN,
+        ciphertext: concat!(
+            "bd6d179d3e83d43b9576579493c0e939572a1700252bfaccbed2902c21396cbb",
+            "731c7f1b0b4aa6440bf3a82f4eda7e39ae64c6708c54c216cb96b72e1213b452",
+            "2f8c9ba40db5d945b11b69b982c1bb9e3f3fac2bc369488f76b2383565d3fff9",
+            "21f9664c97637da9768812f615c68b13b52e",
+            "c0875924c1c7987947deafd8780acf49",
+        ),
+    },
+];
+
+#[cfg(feature = "aead")]
+const SUNSCREEN: &str = concat!(
+    "4c616469657320616e642047656e746c656d656e206f662074686520636c6173",
+    "73206f66202739393a204966204920636f756c64206f6666657220796f75206f",
+    "6e6c79206f6e652074697020666f7220746865206675747572652c2073756e73",
+    "637265656e20776f756c642062652069742e",
+);
+
+#[cfg(feature = "mac")]
+#[derive(Clone, Copy)]
+struct MacVector {
+    algorithm: crate::mac::Algorithm,
+    key: &'static str,
+    data: &'static str,
+    tag: &'static str,
+}
+
+#[cfg(feature = "mac")]
+impl MacVector {
+    fn matches(&self) -> Option<bool> {
+        let mac =
+            crate::Mac::new_external_key(decode(self.key)?, self.algorithm, None, None).ok()?;
+        let data = decode(self.data)?;
+        let tag = mac.compute(&data);
+        let expected = decode(self.tag)?;
+        Some(tag.omit_header().ok()?.as_bytes() == &expected[..] && mac.verify(&tag, &data).is_ok())
+    }
+    fn check(&self) -> Result<(), SelfTestError> {
+        if self.matches() == Some(true) {
+            Ok(())
+        } else {
+            Err(SelfTestError {
+                algorithm: format!("{}", self.algorithm),
+            })
+        }
+    }
+}
+
+/// "Jefe"
+#[cfg(feature = "mac")]
+const JEFE: &str = "4a656665";
+/// "what do ya want for nothing?"
+#[cfg(feature = "mac")]
+const NOTHING: &str = "7768617420646f2079612077616e7420666f72206e6f7468696e673f";
+
+#[cfg(feature = "mac")]
+const MAC_VECTORS: &[MacVector] = &[
+    // RFC 4231, test case 2
+    #[cfg(any(feature = "ring", all(feature = "sha2", feature = "hmac")))]
+    MacVector {
+        algorithm: crate::mac::Algorithm::Sha256,
+        key: JEFE,
+        data: NOTHING,
+        tag: "5bdcc146bf60754e6a042426089575c75a003f089d2739839dec58b964ec3843",
+    },
+    #[cfg(any(feature = "ring", all(feature = "sha2", feature = "hmac")))]
+    MacVector {
+        algorithm: crate::mac::Algorithm::Sha384,
+        key: JEFE,
+        data: NOTHING,
+        tag: concat!(
+            "af45d2e376484031617f78d2b58a6b1b9c7ef464f5a01b47",
+            "e42ec3736322445e8e2240ca5e69e2c78b3239ecfab21649",
+        ),
+    },
+    #[cfg(any(feature = "ring", all(feature = "sha2", feature = "hmac")))]
+    MacVector {
+        algorithm: crate::mac::Algorithm::Sha512,
+        key: JEFE,
+        data: NOTHING,
+        tag: concat!(
+            "164b7a7bfcf819e2e395fbe73b56e0a387bd64222e831fd610270cd7ea250554",
+            "9758bf75c05a994a6d034f65f8f0e6fdcaeab1a34d4a6b4b636e070a38bce737",
+        ),
+    },
+    // the inputs of RFC 4231, test case 2 for the remaining hash functions
+    #[cfg(all(feature = "sha2", feature = "hmac"))]
+    MacVector {
+        algorithm: crate::mac::Algorithm::Sha512_224,
+        key: JEFE,
+        data: NOTHING,
+        tag: "4a530b31a79ebcce36916546317c45f247d83241dfb818fd37254bde",
+    },
+    #[cfg(all(feature = "sha3", feature = "hmac"))]
+    MacVector {
+        algorithm: crate::mac::Algorithm::Sha3_224,
+        key: JEFE,
+        data: NOTHING,
+        tag: "7fdb8dd88bd2f60d1b798634ad386811c2cfc85bfaf5d52bbace5e66",
+    },
+    #[cfg(all(feature = "sha3", feature = "hmac"))]
+    MacVector {
+        algorithm: crate::mac::Algorithm::Sha3_256,
+        key: JEFE,
+        data: NOTHING,
+        tag: "c7d4072e788877ae3596bbb0da73b887c9171f93095b294ae857fbe2645e1ba5",
+    },
+    #[cfg(all(feature = "sha3", feature = "hmac"))]
+    MacVector {
+        algorithm: crate::mac::Algorithm::Sha3_384,
+        key: JEFE,
+        data: NOTHING,
+        tag: concat!(
+            "f1101f8cbf9766fd6764d2ed61903f21ca9b18f57cf3e1a2",
+            "3ca13508a93243ce48c045dc007f26a21b3f5e0e9df4c20a",
+        ),
+    },
+    #[cfg(all(feature = "sha3", feature = "hmac"))]
+    MacVector {
+        algorithm: crate::mac::Algorithm::Sha3_512,
+        key: JEFE,
+        data: NOTHING,
+        tag: concat!(
+            "5a4bfeab6166427c7a3647b747292b8384537cdb89afb3bf5665e4c5e709350b",
+            "287baec921fd7ca0ee7a0c31d022a95e1fc92ba9d77df883960275beb4e62024",
+        ),
+    },
+    // RFC 4493, example 2 and NIST SP 800-38B, appendix D
+    #[cfg(all(feature = "aes", feature = "cmac"))]
+    MacVector {
+        algorithm: crate::mac::Algorithm::Aes128,
+        key: "2b7e151628aed2a6abf7158809cf4f3c",
+        data: "6bc1bee22e409f96e93d7e117393172a",
+        tag: "070a16b46b4d4144f79bdd9dd04a287c",
+    },
+    #[cfg(all(feature = "aes", feature = "cmac"))]
+    MacVector {
+        algorithm: crate::mac::Algorithm::Aes192,
+        key: "8e73b0f7da0e6452c810f32b809079e562f8ead2522c6b7b",
+        data: "6bc1bee22e409f96e93d7e117393172a",
+        tag: "9e99a7bf31e710900662f65e617c5184",
+    },
+    #[cfg(all(feature = "aes", feature = "cmac"))]
+    MacVector {
+        algorithm: crate::mac::Algorithm::Aes256,
+        key: "603deb1015ca71be2b73aef0857d77811f352c073b6108d72d9810a30914dff4",
+        data: "6bc1bee22e409f96e93d7e117393172a",
+        tag: "28a7023f452e8f82bd4bf28d8c37c35c",
+    },
+    #[cfg(feature = "blake3")]
+    MacVector {
+        algorithm: crate::mac::Algorithm::Blake3,
+        // "whats the Elvish word for friend"
+        key: "77686174732074686520456c7669736820776f726420666f7220667269656e64",
+        data: NOTHING,
+        tag: "a238e32aa6492a834315b87970d969a4b5f3cf76a7868e3d66748c01bb5b17f6",
+    },
+];
+
+#[cfg(feature = "hkdf")]
+#[derive(Clone, Copy)]
+struct HkdfVector {
+    algorithm: crate::hkdf::Algorithm,
+    ikm: &'static str,
+    salt: &'static str,
+    info: &'static str,
+    okm: &'static str,
+}
+
+#[cfg(feature = "hkdf")]
+impl HkdfVector {
+    fn matches(&self) -> Option<bool> {
+        let expected = decode(self.okm)?;
+        let prk =
+            crate::hkdf::Salt::new(self.algorithm, &decode(self.salt)?).extract(&decode(self.ikm)?);
+        let mut okm = alloc::vec![0u8; expected.len()];
+        prk.expand(&[&decode(self.info)?], &mut okm).ok()?;
+        Some(okm == expected)
+    }
+    fn check(&self) -> Result<(), SelfTestError> {
+        if self.matches() == Some(true) {
+            Ok(())
+        } else {
+            Err(SelfTestError {
+                algorithm: format!("HKDF-{:?}", self.algorithm),
+            })
+        }
+    }
+}
+
+#[cfg(feature = "hkdf")]
+const HKDF_VECTORS: &[HkdfVector] = &[
+    // RFC 5869, test case 1
+    #[cfg(any(feature = "ring", all(feature = "sha2", feature = "hmac")))]
+    HkdfVector {
+        algorithm: crate::hkdf::Algorithm::Sha256,
+        ikm: "0b0b0b0b0b0b0b0b0b0b0b0b0b0b0b0b0b0b0b0b0b0b",
+        salt: "000102030405060708090a0b0c",
+        info: "f0f1f2f3f4f5f6f7f8f9",
+        okm: concat!(
+            "3cb25f25faacd57a90434f64d0362f2a2d2d0a90cf1a5a4c5db02d56ecc4c5bf",
+            "34007208d5b887185865",
+        ),
+    },
+    // the inputs of RFC 5869, test case 1 for SHA-384 and SHA-512
+    #[cfg(any(feature = "ring", all(feature = "sha2", feature = "hmac")))]
+    HkdfVector {
+        algorithm: crate::hkdf::Algorithm::Sha384,
+        ikm: "0b0b0b0b0b0b0b0b0b0b0b0b0b0b0b0b0b0b0b0b0b0b",
+        salt: "000102030405060708090a0b0c",
+        info: "f0f1f2f3f4f5f6f7f8f9",
+        okm: concat!(
+            "9b5097a86038b805309076a44b3a9f38063e25b516dcbf369f394cfab43685f7",
+            "48b6457763e4f0204fc5",
+        ),
+    },
+    #[cfg(any(feature = "ring", all(feature = "sha2", feature = "hmac")))]
+    HkdfVector {
+        algorithm: crate::hkdf::Algorithm::Sha512,
+        ikm: "0b0b0b0b0b0b0b0b0b0b0b0b0b0b0b0b0b0b0b0b0b0b",
+        salt: "000102030405060708090a0b0c",
+        info: "f0f1f2f3f4f5f6f7f8f9",
+        okm: concat!(
+            "832390086cda71fb47625bb5ceb168e4c8e26a1a16ed34d9fc7fe92c14815793",
+            "38da362cb8d9f925d7cb",
+        ),
+    },
+];
+
+#[cfg(test)]
+mod tests {
+    use super::*;
+
+    #[test]
+    fn test_self_test() {
+        self_test().unwrap();
+    }
+
+    #[cfg(any(feature = "aead", feature = "mac", feature = "hkdf"))]
+    #[test]
+    fn test_decode() {
+        assert_eq!(decode("00ff7a"), Some(alloc::vec![0x00, 0xff, 0x7a]));
+        assert_eq!(decode(""), Some(alloc::vec![]));
+        assert_eq!(decode("0"), None);
+        assert_eq!(decode("zz"), None);
+    }
+
+    #[cfg(feature = "aead")]
+    #[test]
+    fn test_corrupted_aead_vector() {
+        let mut vectors = AEAD_VECTORS.to_vec();
+        vectors[2].ciphertext = concat!(
+            "d31a8d34648e60db7b86afbc53ef7ec2a4aded51296e08fea9e2b5a736ee62d6",
+            "3dbea45e8ca9671282fafb69da92728b1a71de0a9e060b2905d6a5b67ecd3b36",
+            "92ddbd7f2d778b8c9803aee328091b58fab324e4fad675945585808b4831d7bc",
+            "3ff4def08e4b7a9de576d26586cec64b6116",
+            "1ae10b594f09e26a7e902ecbd0600692",
+        );
+        let err = run(&Vectors {
+            aead: &vectors,
+            ..VECTORS
+        })
+        .unwrap_err();
+        assert_eq!(err.algorithm, "ChaCha20-Poly1305");
+    }
+
+    #[cfg(feature = "mac")]
+    #[test]
+    fn test_corrupted_mac_vector() {
+        let mut vectors = MAC_VECTORS.to_vec();
+        vectors[0].data = "7768617420646f2079612077616e7420666f72206e6f7468696e6721";
+        let err = run(&Vectors {
+            mac: &vectors,
+            ..VECTORS
+        })
+        .unwrap_err();
+        assert_eq!(err.algorithm, format!("{}", crate::mac::Algorithm::Sha256));
+    }
+
+    #[cfg(feature = "hkdf")]
+    #[test]
+    fn test_corrupted_hkdf_vector() {
+        let mut vectors = HKDF_VECTORS.to_vec();
+        vectors[0].info = "f0f1f2f3f4f5f6f7f8";
+        let err = run(&Vectors {
+            hkdf: &vectors,
+            ..VECTORS
+        })
+        .unwrap_err();
+        assert!(err.algorithm.starts_with("HKDF-"), "{}", err.algorithm);
+    }
+}
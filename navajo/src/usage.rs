@@ -0,0 +1,32 @@
+use serde::{Deserialize, Serialize};
+
+/// Restricts the operations a key may be used for.
+#[derive(Debug, Clone, Copy, PartialEq, Eq, Hash, Serialize, Deserialize)]
+pub enum Usage {
+    /// The key can be used for all operations. This is the default.
+    EncryptDecrypt,
+    /// The key can only be used to decrypt (or verify) existing data. It
+    /// cannot be promoted to primary nor used to encrypt (or compute) new
+    /// data.
+    ///
+    /// This is intended for retiring a key while data produced by it
+    /// remains in use.
+    DecryptOnly,
+}
+
+impl Default for Usage {
+    fn default() -> Self {
+        Self::EncryptDecrypt
+    }
+}
+
+impl Usage {
+    /// Returns `true` if `EncryptDecrypt`.
+    pub fn is_encrypt_decrypt(&self) -> bool {
+        *self == Self::EncryptDecrypt
+    }
+    /// Returns `true` if `DecryptOnly`.
+    pub fn is_decrypt_only(&self) -> bool {
+        *self == Self::DecryptOnly
+    }
+}